@@ -1,7 +1,15 @@
 package goSummarizer
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"goSummarizer/helpers"
 )
 
 func ExampleCreateFromText() {
@@ -18,6 +26,34 @@ func ExampleCreateFromURL() {
 	s.Summarize()
 }
 
+func ExampleCreateFromHTML() {
+	var archivedPage = `<html><body><h1>Archived page</h1><div class="article">
+		<p>The first paragraph of the archived page. It has two sentences.</p>
+		<p>The second paragraph of the page is shorter</p></div></body></html>`
+
+	var s = CreateFromHTML(archivedPage, "http://testurl.test/")
+	summary, err := s.Summarize()
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+
+	fmt.Println(summary)
+	// Output: Archived page
+	//
+	// The first paragraph of the archived page
+}
+
+func ExampleCreateFromFile() {
+	s, err := CreateFromFile("archive/page.html")
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	// Do something with s
+	s.Summarize()
+}
+
 func ExampleSummarizer_Summarize() {
 	var customNewsStory = `SpaceX has succeeded in launch a Falcon 9 rocket from Vandenberg Air Force Base in California, its first launch since a Falcon 9 rocket exploded on a launch pad in pre-flight procedures in September 2016. The launch took place at 9:54 AM PT Saturday, during an instant launch window. 
 
@@ -60,6 +96,20 @@ func ExampleSummarizer_Summarize_second() {
 	// All satellites were successfully deployed as of 11:13 AM PT / 2:12 PM PT, signalling a successful mission for the space company’s first flight back.
 }
 
+func ExampleSummarizer_SummarizeContext() {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var s = CreateFromText("first sentence. second sentence")
+	summary, err := s.SummarizeContext(ctx)
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+
+	fmt.Println(summary)
+	// Output: first sentence
+}
+
 func ExampleSummarizer_GetSummaryInfo() {
 	var s = CreateFromText("first sentence. second sentence")
 	s.Summarize()
@@ -99,3 +149,159 @@ func ExampleSummarizer_StoreToFile() {
 	fmt.Println(stored)
 	// Output: true
 }
+
+func ExampleSummarizer_GetSummarySentences() {
+	var subtitles = "1\n00:00:01,000 --> 00:00:03,000\nThe quarterly results were better\n\n" +
+		"2\n00:00:03,200 --> 00:00:05,500\nthan expected. Sales grew in every region.\n"
+
+	s, err := CreateFromReader(strings.NewReader(subtitles))
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	s.Summarize()
+	sentences, err := s.GetSummarySentences()
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	for _, sentence := range sentences {
+		fmt.Println("[" + sentence.Timestamp() + "] " + sentence.Text)
+	}
+	// Output: [00:00:01] The quarterly results were better than expected
+}
+
+func ExampleSummarizer_RenderTemplate() {
+	var s = CreateFromText("The first sentence is short. The second sentence is a lot longer than the first sentence.")
+	s.Summarize()
+
+	template, err := helpers.ParseTextTemplate("{{range .Sentences}}* {{.Text}}\n{{end}}Shorter by {{percent .Stats.Ratio}}")
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	err = s.RenderTemplate(os.Stdout, template)
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+	// Output:
+	// * The first sentence is short
+	// Shorter by 69.66%
+}
+
+func ExampleSummarizer_GetChapterSummaries() {
+	var chapters = []*helpers.Summary{
+		{Title: "The harbour", Sentences: []helpers.SummarySentence{
+			{Text: "The harbour was quiet in the morning. Fishing boats came back with the tide. The harbour master counted the boats."},
+			{Text: "Gulls circled above the boats. The fishermen sold their catch on the harbour pier."},
+		}},
+		{Title: "The storm", Sentences: []helpers.SummarySentence{
+			{Text: "A storm came from the west in the evening. The storm broke two masts. The boats stayed in the harbour."},
+			{Text: "By midnight the wind was calm again. The storm moved to the east."},
+		}},
+	}
+
+	// The book is written with the epub writer and read back like an epub file
+	var book strings.Builder
+	var err = helpers.EPUBRenderer{Title: "Harbour stories"}.RenderCollection(&book, chapters)
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	s, err := CreateFromReader(strings.NewReader(book.String()))
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	bookSummary, err := s.Summarize()
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+
+	chapterSummaries, _ := s.GetChapterSummaries()
+	for _, chapter := range chapterSummaries {
+		fmt.Println(chapter.Title + ": " + strings.Replace(chapter.Summary, "\n", " ", -1))
+	}
+	fmt.Println(bookSummary)
+	// Output: The harbour: The harbour master counted the boats. The fishermen sold their catch on the harbour pier.
+	// The storm: The boats stayed in the harbour. The storm moved to the east.
+	// Harbour stories
+	//
+	// The harbour master counted the boats.
+	// The boats stayed in the harbour.
+}
+
+func ExampleStoreToEPUB() {
+	var directory, err = ioutil.TempDir("", "epub-example")
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+	defer os.RemoveAll(directory)
+
+	var first = CreateFromText("The harbour was quiet in the morning. Fishing boats came back with the tide. The harbour master counted the boats.")
+	var second = CreateFromText("A storm came from the west in the evening. The storm broke two masts. The boats stayed in the harbour.")
+	if _, err = StoreToEPUB(filepath.Join(directory, "digest.epub"), "Harbour digest", first, second); err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+
+	first.Summarize()
+	second.Summarize()
+	stored, err := StoreToEPUB(filepath.Join(directory, "digest.epub"), "Harbour digest", first, second)
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+	fmt.Println(stored)
+
+	// The stored book is read back with one chapter per summarizer
+	book, err := CreateFromFile(filepath.Join(directory, "digest.epub"))
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+	book.Summarize()
+	chapterSummaries, _ := book.GetChapterSummaries()
+	for _, chapter := range chapterSummaries {
+		fmt.Println(chapter.Title + ": " + strings.Replace(chapter.Summary, "\n", " ", -1))
+	}
+	// Output: Error occurred:  You must first summarize the text in order to save the summary to a file
+	// true
+	// Article 1: The harbour master counted the boats
+	// Article 2: The boats stayed in the harbour
+}
+
+func ExampleStoreToEPUBContext() {
+	var directory, err = ioutil.TempDir("", "epub-example")
+	if err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+		return
+	}
+	defer os.RemoveAll(directory)
+
+	var s = CreateFromText("first sentence. second sentence", WithWriteMode(helpers.WriteFailIfExists))
+	s.Summarize()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err = StoreToEPUBContext(ctx, filepath.Join(directory, "digest.epub"), "Digest", s); err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+
+	stored, err := StoreToEPUBContext(context.Background(), filepath.Join(directory, "digest.epub"), "Digest", s)
+	fmt.Println(stored, err)
+
+	// The book already exists and the write mode doesn't allow replacing it
+	if _, err = StoreToEPUBContext(context.Background(), filepath.Join(directory, "digest.epub"), "Digest", s); err != nil {
+		fmt.Println("Error occurred: ", err.Error())
+	}
+	// Output: Error occurred:  context canceled
+	// true <nil>
+	// Error occurred:  The file already exists
+}
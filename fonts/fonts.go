@@ -0,0 +1,16 @@
+// Package fonts contains the fonts embedded in the program, so they are available outside of the source tree
+package fonts
+
+import (
+	_ "embed"
+)
+
+// OpenSansRegular is the default font of the pdf files
+//
+//go:embed OpenSans-Regular.ttf
+var OpenSansRegular []byte
+
+// OpenSansBold is the default font of the pdf titles
+//
+//go:embed OpenSans-Bold.ttf
+var OpenSansBold []byte
@@ -0,0 +1,201 @@
+package helpers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached body together with the validators needed to revalidate it
+type CacheEntry struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+}
+
+// Cache stores entries by key. Implementations must be safe for concurrent use
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry) error
+}
+
+// MemoryCache is an in-memory Cache, which evicts the least recently used entries
+type MemoryCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates MemoryCache instance, holding at most capacity entries
+func NewMemoryCache(capacity int) *MemoryCache {
+	var cache = new(MemoryCache)
+	cache.capacity = capacity
+	cache.order = list.New()
+	cache.items = make(map[string]*list.Element)
+	return cache
+}
+
+// Get returns the entry for the key and marks it as recently used
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var element, exists = c.items[key]
+	if !exists {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores the entry for the key, evicting the least recently used entry if the cache is full
+func (c *MemoryCache) Set(key string, entry *CacheEntry) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.items[key]; exists {
+		element.Value.(*memoryCacheItem).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		var oldest = c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryCacheItem).key)
+	}
+
+	return nil
+}
+
+// DirCache is a Cache, which stores every entry as a file in a directory
+type DirCache struct {
+	directory string
+}
+
+// NewDirCache creates DirCache instance in the given directory, creating it if it's missing
+func NewDirCache(directory string) (*DirCache, error) {
+	var err = os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache = new(DirCache)
+	cache.directory = directory
+	return cache, nil
+}
+
+func (c *DirCache) entryPath(key string) string {
+	return filepath.Join(c.directory, hashString(key)+".json")
+}
+
+// Get reads the entry for the key. Missing and unreadable entries are reported as not found
+func (c *DirCache) Get(key string) (*CacheEntry, bool) {
+	var content, err = ioutil.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry = new(CacheEntry)
+	err = json.Unmarshal(content, entry)
+	if err != nil {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Set writes the entry for the key. The file is written to a temporary file first
+// and then renamed, so concurrent readers never see a partial entry
+func (c *DirCache) Set(key string, entry *CacheEntry) error {
+	var content, err = json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(c.directory, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tempFile.Write(content)
+	var closeErr = tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+
+	err = os.Rename(tempFile.Name(), c.entryPath(key))
+	if err != nil {
+		os.Remove(tempFile.Name())
+	}
+
+	return err
+}
+
+func hashString(text string) string {
+	var hash = sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
+
+// CachedSummary is the result of summarizing, stored in a summary cache
+type CachedSummary struct {
+	Title     string
+	Text      string
+	Images    []string
+	Summary   string
+	Chapters  []Chapter         `json:",omitempty"`
+	Sentences []SummarySentence `json:",omitempty"`
+	Metadata  map[string]string `json:",omitempty"`
+}
+
+// SummaryCacheKey builds the summary cache key from the hash of the content and the summarizing options
+func SummaryCacheKey(content string, options ...string) string {
+	return "summary:" + hashString(content) + ":" + strings.Join(options, ",")
+}
+
+// GetCachedSummary reads the summary stored for the key
+func GetCachedSummary(cache Cache, key string) (*CachedSummary, bool) {
+	var entry, found = cache.Get(key)
+	if !found {
+		return nil, false
+	}
+
+	var summary = new(CachedSummary)
+	var err = json.Unmarshal(entry.Body, summary)
+	if err != nil {
+		return nil, false
+	}
+
+	return summary, true
+}
+
+// StoreCachedSummary stores the summary for the key
+func StoreCachedSummary(cache Cache, key string, summary *CachedSummary) error {
+	var body, err = json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	return cache.Set(key, &CacheEntry{Body: body, StoredAt: time.Now()})
+}
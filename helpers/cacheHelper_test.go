@@ -0,0 +1,98 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var cache = NewMemoryCache(2)
+	cache.Set("first", &CacheEntry{Body: []byte("1")})
+	cache.Set("second", &CacheEntry{Body: []byte("2")})
+
+	// Using the first entry makes the second one the least recently used
+	cache.Get("first")
+	cache.Set("third", &CacheEntry{Body: []byte("3")})
+
+	if _, found := cache.Get("second"); found {
+		t.Error("Expected 'second' to be evicted but it wasn't")
+	}
+
+	if _, found := cache.Get("first"); !found {
+		t.Error("Expected 'first' to be cached but it wasn't")
+	}
+
+	if _, found := cache.Get("third"); !found {
+		t.Error("Expected 'third' to be cached but it wasn't")
+	}
+}
+
+func TestDirCacheStoringAndReading(t *testing.T) {
+	var cache, err = NewDirCache(t.TempDir())
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	err = cache.Set("http://test.test/", &CacheEntry{Body: []byte("body"), ETag: "\"123\""})
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var entry, found = cache.Get("http://test.test/")
+	if !found {
+		t.Fatal("Expected cached entry but received none")
+	}
+
+	if string(entry.Body) != "body" || entry.ETag != "\"123\"" {
+		t.Error("Expected stored body and etag but received: ", string(entry.Body), entry.ETag)
+	}
+
+	if _, found := cache.Get("http://missing.test/"); found {
+		t.Error("Expected missing entry not to be found")
+	}
+}
+
+func TestFetchingRevalidatesCachedResponses(t *testing.T) {
+	var downloads = 0
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "\"v1\"" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		downloads++
+		w.Header().Set("ETag", "\"v1\"")
+		w.Write([]byte("page body"))
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.Cache = NewMemoryCache(10)
+
+	for i := 0; i < 3; i++ {
+		var body, err = fetcher.Fetch(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+
+		if string(body) != "page body" {
+			t.Error("Expected 'page body' but received: ", string(body))
+		}
+	}
+
+	if downloads != 1 {
+		t.Error("Expected 1 download but received ", downloads)
+	}
+}
+
+func TestSummaryCacheKeyDependsOnOptions(t *testing.T) {
+	if SummaryCacheKey("text", "a") == SummaryCacheKey("text", "b") {
+		t.Error("Expected different keys for different options")
+	}
+
+	if SummaryCacheKey("text", "a") != SummaryCacheKey("text", "a") {
+		t.Error("Expected the same key for the same content and options")
+	}
+}
@@ -0,0 +1,285 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DOCXRenderer writes the summary as Word document. The sentences are bullets or paragraphs if Paragraphs is set.
+// The images are downloaded with the fetcher or with DefaultFetcher if it's nil and embedded in the document.
+// MaxImages, ImageDir and AllowLocalImages work like in ImageOptions. Downloading is aborted when the context is done
+type DOCXRenderer struct {
+	Context          context.Context
+	Fetcher          Fetcher
+	Paragraphs       bool
+	MaxImages        int
+	ImageDir         string
+	AllowLocalImages bool
+}
+
+// Sizes of the images in the Word documents. The images are scaled down to fit in the page width
+// and the pixels are converted to EMU, the unit of the drawings, by 96 pixels per inch
+const (
+	docxMaxImageWidth  = 600
+	docxMaxImageHeight = 800
+	docxEMUPerPixel    = 9525
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpeg" ContentType="image/jpeg"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxPackageRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/><w:szCs w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:color w:val="2F5496"/><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:color w:val="2F5496"/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="80"/><w:ind w:left="720"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Caption"><w:name w:val="caption"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:rPr><w:i/><w:color w:val="595959"/><w:sz w:val="18"/><w:szCs w:val="18"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/></w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
+
+const docxNumbering = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="singleLevel"/><w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl></w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+</w:numbering>`
+
+// Render writes the title, the metadata table with the link to the source, the summary, the chapter summaries and the images
+func (r DOCXRenderer) Render(w io.Writer, summary *Summary) error {
+	var ctx = r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var images, err = loadEmbeddedImages(ctx, r.Fetcher, summary.Images, ImageOptions{MaxImages: r.MaxImages, ImageDir: r.ImageDir, AllowLocalImages: r.AllowLocalImages})
+	if err != nil {
+		return err
+	}
+
+	var archive = zip.NewWriter(w)
+	var files = []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxPackageRelationships)},
+		{"docProps/core.xml", buildDOCXCoreProperties(summary)},
+		{"word/styles.xml", []byte(docxStyles)},
+		{"word/numbering.xml", []byte(docxNumbering)},
+		{"word/_rels/document.xml.rels", buildDOCXRelationships(summary, images)},
+		{"word/document.xml", r.buildDocument(summary, images)},
+	}
+	for i, image := range images {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{"word/media/" + image.fileName(i+1), image.data})
+	}
+
+	for _, file := range files {
+		var fileWriter, err = archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = fileWriter.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func buildDOCXCoreProperties(summary *Summary) []byte {
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	content.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`)
+	content.WriteString("<dc:title>" + escapeXMLText(summary.Title) + "</dc:title>")
+	if author := summary.Metadata[MetadataAuthor]; author != "" {
+		content.WriteString("<dc:creator>" + escapeXMLText(author) + "</dc:creator>")
+	}
+	if description := summary.Metadata[MetadataDescription]; description != "" {
+		content.WriteString("<dc:description>" + escapeXMLText(description) + "</dc:description>")
+	}
+	if len(summary.Keywords) > 0 {
+		content.WriteString("<cp:keywords>" + escapeXMLText(strings.Join(summary.Keywords, ", ")) + "</cp:keywords>")
+	}
+	if language := summary.Metadata[MetadataLanguage]; language != "" {
+		content.WriteString("<dc:language>" + escapeXMLText(language) + "</dc:language>")
+	}
+	content.WriteString(`<dcterms:created xsi:type="dcterms:W3CDTF">` + time.Now().UTC().Format(time.RFC3339) + "</dcterms:created>")
+	content.WriteString("</cp:coreProperties>")
+	return content.Bytes()
+}
+
+func buildDOCXRelationships(summary *Summary, images []embeddedImage) []byte {
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	content.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	content.WriteString(`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	content.WriteString(`<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	if isWebURL(summary.SourceURL) {
+		content.WriteString(`<Relationship Id="rIdSource" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" ` +
+			`Target="` + escapeXMLText(summary.SourceURL) + `" TargetMode="External"/>`)
+	}
+	for i, image := range images {
+		content.WriteString(`<Relationship Id="rIdImage` + strconv.Itoa(i+1) + `" ` +
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/` + image.fileName(i+1) + `"/>`)
+	}
+	content.WriteString("</Relationships>")
+	return content.Bytes()
+}
+
+func (r DOCXRenderer) buildDocument(summary *Summary, images []embeddedImage) []byte {
+	var body bytes.Buffer
+	if summary.Title != "" {
+		body.WriteString(docxStyledParagraph("Title", "", summary.Title))
+	}
+
+	writeDOCXMetadataTable(&body, summary)
+
+	body.WriteString(docxStyledParagraph("Heading1", "", "Summary"))
+	r.writeSentences(&body, summary.SentenceTexts())
+
+	for _, chapter := range summary.Chapters {
+		if chapter.Summary == "" {
+			continue
+		}
+
+		body.WriteString(docxStyledParagraph("Heading2", "", chapter.Title))
+		r.writeSentences(&body, strings.Split(chapter.Summary, "\n"))
+	}
+
+	if len(images) > 0 {
+		body.WriteString(docxStyledParagraph("Heading1", "", "Images"))
+	}
+	for i, image := range images {
+		var number = strconv.Itoa(i + 1)
+		var width, height = scaleImage(image.width, image.height, docxMaxImageWidth, docxMaxImageHeight)
+		var extent = `cx="` + strconv.Itoa(int(width)*docxEMUPerPixel) + `" cy="` + strconv.Itoa(int(height)*docxEMUPerPixel) + `"`
+		body.WriteString(`<w:p><w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`)
+		body.WriteString(`<wp:extent ` + extent + `/>`)
+		body.WriteString(`<wp:docPr id="` + number + `" name="Picture ` + number + `"/>`)
+		body.WriteString(`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+		body.WriteString(`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+		body.WriteString(`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+		body.WriteString(`<pic:nvPicPr><pic:cNvPr id="` + number + `" name="` + image.fileName(i+1) + `"/><pic:cNvPicPr/></pic:nvPicPr>`)
+		body.WriteString(`<pic:blipFill><a:blip r:embed="rIdImage` + number + `"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`)
+		body.WriteString(`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext ` + extent + `/></a:xfrm>`)
+		body.WriteString(`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>`)
+		body.WriteString(docxStyledParagraph("Caption", "", "Figure "+number+": "+image.source))
+	}
+
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	content.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"><w:body>`)
+	content.Write(body.Bytes())
+	// A4 page with margins of one inch, in twentieths of a point
+	content.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`)
+	content.WriteString("</w:body></w:document>")
+	return content.Bytes()
+}
+
+// writeDOCXMetadataTable writes a table with the source, linked if it's a web page, and the metadata, sorted by key
+func writeDOCXMetadataTable(body *bytes.Buffer, summary *Summary) {
+	var keys = make([]string, 0, len(summary.Metadata))
+	for key := range summary.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if summary.SourceURL == "" && len(keys) == 0 {
+		return
+	}
+
+	var writeRow = func(name string, value string) {
+		body.WriteString(`<w:tr><w:tc><w:tcPr><w:tcW w:w="1500" w:type="pct"/></w:tcPr><w:p><w:r><w:rPr><w:b/></w:rPr>` +
+			docxText(name) + `</w:r></w:p></w:tc><w:tc><w:tcPr><w:tcW w:w="3500" w:type="pct"/></w:tcPr>` + value + `</w:tc></w:tr>`)
+	}
+
+	body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr>`)
+	body.WriteString(`<w:tblGrid><w:gridCol w:w="2700"/><w:gridCol w:w="6326"/></w:tblGrid>`)
+	if isWebURL(summary.SourceURL) {
+		writeRow("Source", `<w:p><w:hyperlink r:id="rIdSource" w:history="1"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr>`+
+			docxText(summary.SourceURL)+`</w:r></w:hyperlink></w:p>`)
+	} else if summary.SourceURL != "" {
+		writeRow("Source", docxStyledParagraph("", "", summary.SourceURL))
+	}
+	for _, key := range keys {
+		writeRow(metadataLabel(key), docxStyledParagraph("", "", summary.Metadata[key]))
+	}
+	body.WriteString(`</w:tbl>`)
+	body.WriteString(docxStyledParagraph("", "", ""))
+}
+
+// writeSentences writes the sentences as bullets or as paragraphs
+func (r DOCXRenderer) writeSentences(body *bytes.Buffer, sentences []string) {
+	for _, sentence := range sentences {
+		if strings.TrimSpace(sentence) == "" {
+			continue
+		}
+
+		if r.Paragraphs {
+			body.WriteString(docxStyledParagraph("", "", sentence))
+		} else {
+			body.WriteString(docxStyledParagraph("ListParagraph", `<w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr>`, sentence))
+		}
+	}
+}
+
+// docxStyledParagraph returns a paragraph with the style and the additional paragraph properties
+func docxStyledParagraph(style string, properties string, text string) string {
+	var paragraph strings.Builder
+	paragraph.WriteString("<w:p>")
+	if style != "" || properties != "" {
+		paragraph.WriteString("<w:pPr>")
+		if style != "" {
+			paragraph.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+		}
+		paragraph.WriteString(properties + "</w:pPr>")
+	}
+	if text != "" {
+		paragraph.WriteString("<w:r>" + docxText(text) + "</w:r>")
+	}
+	paragraph.WriteString("</w:p>")
+	return paragraph.String()
+}
+
+func docxText(text string) string {
+	return `<w:t xml:space="preserve">` + escapeXMLText(text) + "</w:t>"
+}
+
+func escapeXMLText(text string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image"
+	"image/gif"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenderingDOCX(t *testing.T) {
+	var encodeGIF = func(content *bytes.Buffer, picture image.Image) error { return gif.Encode(content, picture, nil) }
+	var fetcher = mapFetcher{"https://example.com/photo.gif": createTestImage(t, 300, 200, encodeGIF)}
+
+	var summary = &Summary{
+		Title:     "Mountain <trip>",
+		SourceURL: "https://example.com/trip?day=1&night=2",
+		Metadata:  map[string]string{MetadataAuthor: "Jane Doe"},
+		Sentences: []SummarySentence{{Text: "The first sentence & more."}, {Text: "The second sentence."}},
+		Keywords:  []string{"mountain", "trip"},
+		Images:    []string{"https://example.com/photo.gif", "https://example.com/missing.png"},
+	}
+
+	var content bytes.Buffer
+	if err := RenderSummary(context.Background(), &content, "docx", summary, fetcher); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content.Bytes()), int64(content.Len()))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var files = map[string]string{}
+	for _, file := range archive.File {
+		var reader, err = file.Open()
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		files[file.Name] = string(data)
+
+		if strings.HasSuffix(file.Name, ".xml") || strings.HasSuffix(file.Name, ".rels") {
+			var decoder = xml.NewDecoder(bytes.NewReader(data))
+			for {
+				if _, err := decoder.Token(); err != nil {
+					if err.Error() != "EOF" {
+						t.Error("Expected well-formed xml in "+file.Name+" but received: ", err.Error())
+					}
+					break
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels", "word/media/image1.png"} {
+		if _, found := files[name]; !found {
+			t.Error("Expected the package to contain: ", name)
+		}
+	}
+	if _, found := files["word/media/image2.png"]; found {
+		t.Error("Expected the missing image to be skipped")
+	}
+	if !strings.Contains(files["word/_rels/document.xml.rels"], `Target="https://example.com/trip?day=1&amp;night=2" TargetMode="External"`) {
+		t.Error("Expected a hyperlink to the source but received: ", files["word/_rels/document.xml.rels"])
+	}
+	if !strings.Contains(files["docProps/core.xml"], "<dc:creator>Jane Doe</dc:creator>") {
+		t.Error("Expected the author in the core properties but received: ", files["docProps/core.xml"])
+	}
+
+	// Both extents of the picture are in EMU, 9525 per pixel
+	var extents = regexp.MustCompile(`<(?:wp:extent|a:ext) (cx="\d+" cy="\d+")/>`).FindAllStringSubmatch(files["word/document.xml"], -1)
+	if len(extents) != 2 || extents[0][1] != `cx="2857500" cy="1905000"` || extents[1][1] != extents[0][1] {
+		t.Error("Expected the same extents in EMU for the picture but received: ", extents)
+	}
+
+	document, err := ReadDocument(content.Bytes(), FormatDOCX, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if document.Title != "Mountain <trip>" {
+		t.Error("Expected title 'Mountain <trip>' but received: ", document.Title)
+	}
+	for _, text := range []string{"Jane Doe", "The first sentence & more.", "The second sentence.", "Figure 1: https://example.com/photo.gif"} {
+		if !strings.Contains(document.Text, text) {
+			t.Error("Expected the document to contain '"+text+"' but received: ", document.Text)
+		}
+	}
+}
+
+func TestRenderingDOCXWithLocalSource(t *testing.T) {
+	var summary = &Summary{Title: "Local", SourceURL: "/home/user/notes.txt", Sentences: []SummarySentence{{Text: "The only sentence."}}}
+
+	var content bytes.Buffer
+	if err := RenderSummary(context.Background(), &content, "docx", summary, nil); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content.Bytes()), int64(content.Len()))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	relationships, err := readZipFile(archive, "word/_rels/document.xml.rels")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	document, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if strings.Contains(string(relationships), "rIdSource") || strings.Contains(string(document), "w:hyperlink") {
+		t.Error("Expected no hyperlink to the local source but received: ", string(document))
+	}
+	if !strings.Contains(string(document), "/home/user/notes.txt") {
+		t.Error("Expected the local source as plain text but received: ", string(document))
+	}
+}
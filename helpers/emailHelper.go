@@ -0,0 +1,429 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxMIMEDepth limits the nesting of multipart bodies, e.g. forwarded messages with attachments
+const maxMIMEDepth = 10
+
+var replyPrefix, _ = regexp.Compile("(?i)^((re|fw|fwd|aw|wg|sv)(\\[[0-9]+\\])?:\\s*)+")
+var replyAttribution, _ = regexp.Compile("(?i)^(on\\s.+(wrote|writes)\\s*:|.+\\s(wrote|writes)\\s*:)$")
+var forwardedHeader, _ = regexp.Compile("(?i)^-+\\s*(original message|forwarded message|ursprüngliche nachricht)\\s*-+$")
+var outlookHeader, _ = regexp.Compile("(?i)^(from|von|de):\\s.+")
+var mobileSignature, _ = regexp.Compile("(?i)^(sent from my |get outlook for |sent from mail for )")
+
+// emailHeaderNames are headers, which almost every message starts with
+var emailHeaderNames = []string{"from:", "to:", "subject:", "date:", "received:", "return-path:", "message-id:", "mime-version:", "delivered-to:"}
+
+// readEmailDocument reads the subject and the body of a single message. The sender and the date are kept as metadata
+func readEmailDocument(data []byte) (*Document, error) {
+	var subject, text, err = readEmailMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var document = &Document{Title: subject, Text: text, Metadata: make(map[string]string)}
+	if message, err := mail.ReadMessage(bytes.NewReader(data)); err == nil {
+		var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+		if from, err := decoder.DecodeHeader(message.Header.Get("From")); err == nil && from != "" {
+			document.Metadata[MetadataAuthor] = collapseSpaces(from)
+		}
+		if date := message.Header.Get("Date"); date != "" {
+			document.Metadata[MetadataPublished] = collapseSpaces(date)
+		}
+	}
+
+	return document, nil
+}
+
+// readMboxDocument reads all messages of the mailbox in order. The title is the subject of the first message
+func readMboxDocument(data []byte) (*Document, error) {
+	var document = new(Document)
+	var texts = []string{}
+
+	for _, message := range splitMbox(data) {
+		var subject, text, err = readEmailMessage(message)
+		if err != nil {
+			continue
+		}
+
+		if document.Title == "" {
+			document.Title = subject
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil, errors.New("The mailbox has no readable messages")
+	}
+
+	document.Text = strings.Join(texts, "\n\n")
+	return document, nil
+}
+
+// splitMbox splits the mailbox on the "From " separator lines and unescapes the ">From " body lines
+func splitMbox(data []byte) [][]byte {
+	var messages = [][]byte{}
+	var current []byte
+	var previousEmpty = true
+
+	var scanner = bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var line = scanner.Bytes()
+		if previousEmpty && bytes.HasPrefix(line, []byte("From ")) {
+			if current != nil {
+				messages = append(messages, current)
+			}
+			current = []byte{}
+			previousEmpty = false
+			continue
+		}
+
+		if current == nil {
+			// Content before the first separator is not a message
+			continue
+		}
+
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) && line[0] == '>' {
+			line = line[1:]
+		}
+
+		current = append(current, line...)
+		current = append(current, '\n')
+		previousEmpty = len(bytes.TrimSpace(line)) == 0
+	}
+
+	if current != nil {
+		messages = append(messages, current)
+	}
+
+	return messages
+}
+
+// readEmailMessage returns the decoded subject and the cleaned body text of the message
+func readEmailMessage(data []byte) (string, string, error) {
+	var message, err = mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return "", "", err
+	}
+
+	var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+	var subject, decodeErr = decoder.DecodeHeader(message.Header.Get("Subject"))
+	if decodeErr != nil {
+		subject = message.Header.Get("Subject")
+	}
+	subject = strings.TrimSpace(replyPrefix.ReplaceAllString(collapseSpaces(subject), ""))
+
+	var plainText, htmlText = "", ""
+	err = readEmailPart(message.Header, message.Body, 0, &plainText, &htmlText)
+	if err != nil {
+		return "", "", err
+	}
+
+	var text = plainText
+	if strings.TrimSpace(text) == "" && htmlText != "" {
+		_, text, _, err = ExtractMainInfoFromHTML(htmlText)
+		if err != nil {
+			return "", "", err
+		}
+		// The html extractor already joins the lines of a paragraph
+		return subject, strings.TrimSpace(text), nil
+	}
+
+	return subject, cleanEmailText(text), nil
+}
+
+// emailHeader gives access to the headers of messages and multipart parts in the same way
+type emailHeader interface {
+	Get(key string) string
+}
+
+// readEmailPart walks the MIME tree and keeps the first text/plain and text/html bodies, which aren't attachments
+func readEmailPart(header emailHeader, body io.Reader, depth int, plainText *string, htmlText *string) error {
+	var mediaType, parameters, err = mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// Messages without a valid content type are plain text by default
+		mediaType = "text/plain"
+		parameters = map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth || parameters["boundary"] == "" {
+			return nil
+		}
+
+		// Parts which can't be decoded, e.g. because of an unknown charset, are skipped.
+		// Their error is returned only if no other part could be read
+		var partErr error
+		var reader = multipart.NewReader(body, parameters["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				// A broken part ends the multipart body, but the parts read so far are still usable
+				break
+			}
+
+			err = readEmailPart(part.Header, part, depth+1, plainText, htmlText)
+			if err != nil && partErr == nil {
+				partErr = err
+			}
+		}
+
+		if *plainText == "" && *htmlText == "" {
+			return partErr
+		}
+		return nil
+	}
+
+	var disposition, _, _ = mime.ParseMediaType(header.Get("Content-Disposition"))
+	if disposition == "attachment" || (mediaType != "text/plain" && mediaType != "text/html") {
+		return nil
+	}
+
+	if (mediaType == "text/plain" && *plainText != "") || (mediaType == "text/html" && *htmlText != "") {
+		return nil
+	}
+
+	content, err := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return err
+	}
+
+	text, err := decodeCharset(content, parameters["charset"])
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/plain" {
+		if strings.EqualFold(parameters["format"], "flowed") {
+			text = unwrapFlowedText(text, strings.EqualFold(parameters["delsp"], "yes"))
+		}
+		*plainText = text
+	} else {
+		*htmlText = text
+	}
+
+	return nil
+}
+
+// decodeTransferEncoding decodes quoted-printable and base64 bodies. Other encodings are already plain
+func decodeTransferEncoding(encoding string, body io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return ioutil.ReadAll(quotedprintable.NewReader(body))
+	case "base64":
+		// The base64 lines are wrapped, so the new lines are removed before decoding
+		var content, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		var cleaned = strings.TrimRight(strings.Join(strings.Fields(string(content)), ""), "=")
+		return base64.RawStdEncoding.DecodeString(cleaned)
+	}
+
+	return ioutil.ReadAll(body)
+}
+
+// decodeCharset converts the content from the charset to UTF-8 with the charsets known by the browsers.
+// Like in the browsers, Latin-1 and ASCII are read as Windows-1252, which is their superset. Content without
+// charset or with unknown charset is returned with an error instead of garbled text
+func decodeCharset(content []byte, charset string) (string, error) {
+	charset = strings.TrimSpace(charset)
+	if charset == "" {
+		return string(content), nil
+	}
+
+	var encoding, err = htmlindex.Get(charset)
+	if err != nil {
+		return "", errors.New("Unsupported charset: " + charset)
+	}
+
+	var name, _ = htmlindex.Name(encoding)
+	if name == "utf-8" || (name == "windows-1252" && utf8.Valid(content)) {
+		// 7 bit and mislabeled UTF-8 content are kept as they are
+		return string(content), nil
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// charsetReader lets the mime.WordDecoder decode the charsets in the headers
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	var content, err = ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := decodeCharset(content, charset)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(text), nil
+}
+
+// unwrapFlowedText joins the soft broken lines of format=flowed text (RFC 3676)
+func unwrapFlowedText(text string, deleteSpace bool) string {
+	var result strings.Builder
+	for _, line := range strings.Split(normalizeText(text), "\n") {
+		// Space stuffed lines start with an additional space
+		line = strings.TrimPrefix(line, " ")
+		if strings.HasSuffix(line, " ") && line != "-- " {
+			if deleteSpace {
+				line = strings.TrimSuffix(line, " ")
+			}
+			result.WriteString(line)
+			continue
+		}
+		result.WriteString(line + "\n")
+	}
+
+	return result.String()
+}
+
+// cleanEmailText removes the quoted replies, forwarded history and signature of the message
+// and joins the wrapped lines of every paragraph
+func cleanEmailText(text string) string {
+	var lines = strings.Split(normalizeText(text), "\n")
+	var paragraphs = []string{}
+	var currentParagraph = []string{}
+
+	var endParagraph = func() {
+		if len(currentParagraph) > 0 {
+			paragraphs = append(paragraphs, strings.Join(currentParagraph, " "))
+			currentParagraph = []string{}
+		}
+	}
+
+	for i, line := range lines {
+		var trimmedLine = strings.TrimSpace(line)
+
+		// Everything after the signature separator or the quoted history is not part of the message
+		if line == "-- " || trimmedLine == "--" || forwardedHeader.MatchString(trimmedLine) || isOutlookHistory(lines, i) {
+			break
+		}
+
+		if strings.HasPrefix(trimmedLine, ">") || replyAttribution.MatchString(trimmedLine) || mobileSignature.MatchString(trimmedLine) {
+			endParagraph()
+			continue
+		}
+
+		// Attributions are often wrapped to two lines, e.g. "On Mon, 1 Jan 2018, John Doe" / "<john@doe.test> wrote:"
+		if i+1 < len(lines) && replyAttribution.MatchString(trimmedLine+" "+strings.TrimSpace(lines[i+1])) &&
+			strings.HasPrefix(strings.ToLower(trimmedLine), "on ") && isQuoteFollowing(lines, i+2) {
+			endParagraph()
+			continue
+		}
+
+		if trimmedLine == "" {
+			endParagraph()
+			continue
+		}
+
+		currentParagraph = append(currentParagraph, trimmedLine)
+	}
+	endParagraph()
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// isOutlookHistory checks if the line starts the header block, which Outlook puts before the quoted message
+func isOutlookHistory(lines []string, index int) bool {
+	var line = strings.TrimSpace(lines[index])
+	var isSeparator = len(line) >= 10 && strings.Trim(line, "_") == ""
+	if isSeparator && index+1 < len(lines) {
+		line = strings.TrimSpace(lines[index+1])
+		index++
+	} else if isSeparator {
+		return false
+	}
+
+	if !outlookHeader.MatchString(line) {
+		return false
+	}
+
+	// "From:" alone might be part of the text, so we require another header after it
+	for _, nextLine := range lines[index+1 : minIndex(index+4, len(lines))] {
+		var lowerLine = strings.ToLower(strings.TrimSpace(nextLine))
+		for _, header := range []string{"sent:", "date:", "to:", "subject:", "gesendet:", "envoyé:"} {
+			if strings.HasPrefix(lowerLine, header) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isQuoteFollowing checks if the first non-empty line from the index is quoted
+func isQuoteFollowing(lines []string, index int) bool {
+	for ; index < len(lines); index++ {
+		var line = strings.TrimSpace(lines[index])
+		if line != "" {
+			return strings.HasPrefix(line, ">")
+		}
+	}
+
+	return true
+}
+
+func minIndex(first int, second int) int {
+	if first < second {
+		return first
+	}
+	return second
+}
+
+// sniffEmailFormat detects messages by their header block and mailboxes by the "From " separator before it
+func sniffEmailFormat(data []byte) string {
+	if bytes.HasPrefix(data, []byte("From ")) {
+		var lineEnd = bytes.IndexByte(data, '\n')
+		if lineEnd > 0 && sniffEmailFormat(data[lineEnd+1:]) == FormatEmail {
+			return FormatMbox
+		}
+		return ""
+	}
+
+	var headerEnd = bytes.Index(data, []byte("\n\n"))
+	if crlfEnd := bytes.Index(data, []byte("\r\n\r\n")); crlfEnd >= 0 && (headerEnd < 0 || crlfEnd < headerEnd) {
+		headerEnd = crlfEnd
+	}
+	if headerEnd <= 0 {
+		return ""
+	}
+
+	var knownHeaders = 0
+	for _, line := range strings.Split(strings.ToLower(string(data[:headerEnd])), "\n") {
+		for _, header := range emailHeaderNames {
+			if strings.HasPrefix(line, header) {
+				knownHeaders++
+			}
+		}
+	}
+
+	if knownHeaders >= 2 {
+		return FormatEmail
+	}
+
+	return ""
+}
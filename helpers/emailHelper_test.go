@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+var testEmail = strings.Join([]string{
+	"From: Jane Doe <jane@test.test>",
+	"To: team@test.test",
+	"Subject: Re: =?ISO-8859-1?Q?Caf=E9_budget?=",
+	"MIME-Version: 1.0",
+	"Content-Type: multipart/alternative; boundary=\"frontier\"",
+	"",
+	"--frontier",
+	"Content-Type: text/plain; charset=utf-8",
+	"Content-Transfer-Encoding: quoted-printable",
+	"",
+	"The budget for the caf=C3=A9 is approved and the works start in May. The=",
+	" contractor was",
+	"chosen last week.",
+	"",
+	"On Mon, 1 Jan 2018, John Doe",
+	"<john@test.test> wrote:",
+	"> Is the budget approved?",
+	">> It was sent last week.",
+	"",
+	"-- ",
+	"Jane Doe",
+	"Facility manager",
+	"--frontier",
+	"Content-Type: text/html; charset=utf-8",
+	"",
+	"<html><body><p>Html version.</p></body></html>",
+	"--frontier--",
+	"",
+}, "\r\n")
+
+func TestReadingEmailMessage(t *testing.T) {
+	if format := DetectFormat("", []byte(testEmail)); format != FormatEmail {
+		t.Error("Expected email format but received: ", format)
+	}
+
+	var document, err = ReadDocument([]byte(testEmail), FormatEmail, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Café budget" {
+		t.Error("Expected the decoded subject without the reply prefix but received: ", document.Title)
+	}
+
+	var expectedText = "The budget for the café is approved and the works start in May. The contractor was chosen last week."
+	if document.Text != expectedText {
+		t.Error("Expected the plain text without quotes and signature but received: ", document.Text)
+	}
+}
+
+func TestReadingCyrillicEmailMessages(t *testing.T) {
+	// "Бюджетът е одобрен." in windows-1251 and in koi8-r
+	var bodies = map[string]string{
+		"windows-1251": "\xc1\xfe\xe4\xe6\xe5\xf2\xfa\xf2 \xe5 \xee\xe4\xee\xe1\xf0\xe5\xed.",
+		"koi8-r":       "\xe2\xc0\xc4\xd6\xc5\xd4\xdf\xd4 \xc5 \xcf\xc4\xcf\xc2\xd2\xc5\xce.",
+	}
+
+	for charset, body := range bodies {
+		var message = "From: a@test.test\nSubject: News\nContent-Type: text/plain; charset=" + charset + "\n\n" + body + "\n"
+		var document, err = ReadDocument([]byte(message), FormatEmail, "")
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		if document.Text != "Бюджетът е одобрен." {
+			t.Error("Expected the decoded "+charset+" text but received: ", document.Text)
+		}
+	}
+
+	var message = "From: a@test.test\nSubject: News\nContent-Type: text/plain; charset=x-unknown\n\n\xc1\xfe\n"
+	if _, err := ReadDocument([]byte(message), FormatEmail, ""); err == nil {
+		t.Error("Expected error for unsupported charset but received none")
+	}
+}
+
+func TestReadingHTMLEmailMessage(t *testing.T) {
+	var message = "From: a@test.test\nSubject: News\nContent-Type: text/html; charset=utf-8\nContent-Transfer-Encoding: base64\n\n" +
+		"PGh0bWw+PGJvZHk+PGRpdj48cD5GaXJzdCBzZW50ZW5jZS4gU2Vjb25kIHNlbnRl\nbmNlLjwvcD48L2Rpdj48L2JvZHk+PC9odG1sPg==\n"
+
+	var document, err = ReadDocument([]byte(message), FormatEmail, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Text != "First sentence. Second sentence." {
+		t.Error("Expected the text of the html body but received: ", document.Text)
+	}
+}
+
+func TestReadingMbox(t *testing.T) {
+	var mbox = "From jane@test.test Mon Jan  1 10:00:00 2018\nFrom: jane@test.test\nSubject: Plans\n\nFirst message text.\n>From now on we meet on Mondays.\n\n" +
+		"From john@test.test Mon Jan  1 11:00:00 2018\nFrom: john@test.test\nSubject: Re: Plans\n\nSecond message text.\n\nOutlook history follows.\n________________________________\nFrom: Jane\nSent: Monday\n\nFirst message text.\n"
+
+	if format := DetectFormat("", []byte(mbox)); format != FormatMbox {
+		t.Error("Expected mbox format but received: ", format)
+	}
+
+	var document, err = ReadDocument([]byte(mbox), FormatMbox, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Plans" {
+		t.Error("Expected the subject of the first message but received: ", document.Title)
+	}
+
+	var expectedText = "First message text. From now on we meet on Mondays.\n\nSecond message text.\n\nOutlook history follows."
+	if document.Text != expectedText {
+		t.Error("Expected the messages in order without history but received: ", document.Text)
+	}
+}
+
+func TestReadingMboxWithUnknownCharsetPart(t *testing.T) {
+	var mbox = "From jane@test.test Mon Jan  1 10:00:00 2018\nFrom: jane@test.test\nSubject: Plans\n" +
+		"Content-Type: multipart/mixed; boundary=\"frontier\"\n\n" +
+		"--frontier\nContent-Type: text/plain; charset=x-unknown\n\n\xc1\xfe\n" +
+		"--frontier\nContent-Type: text/plain; charset=utf-8\n\nReadable message text.\n--frontier--\n\n" +
+		"From john@test.test Mon Jan  1 11:00:00 2018\nFrom: john@test.test\nSubject: Re: Plans\n" +
+		"Content-Type: multipart/alternative; boundary=\"frontier\"\n\n" +
+		"--frontier\nContent-Type: text/plain; charset=x-unknown\n\n\xc1\xfe\n--frontier--\n\n" +
+		"From jim@test.test Mon Jan  1 12:00:00 2018\nFrom: jim@test.test\nSubject: Re: Plans\n\nLast message text.\n"
+
+	var document, err = ReadDocument([]byte(mbox), FormatMbox, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	// The second message has no readable part, so only it is skipped
+	var expectedText = "Readable message text.\n\nLast message text."
+	if document.Text != expectedText {
+		t.Error("Expected the readable parts of the messages but received: ", document.Text)
+	}
+
+	var _, _, messageErr = readEmailMessage([]byte("Subject: News\nContent-Type: multipart/mixed; boundary=\"frontier\"\n\n" +
+		"--frontier\nContent-Type: text/plain; charset=x-unknown\n\n\xc1\xfe\n--frontier--\n"))
+	if messageErr == nil {
+		t.Error("Expected error for message without readable parts but received none")
+	}
+}
+
+func TestSniffingTextStartingWithFrom(t *testing.T) {
+	if format := DetectFormat("", []byte("From the beginning it was clear.\n\nSecond paragraph.")); format != FormatText {
+		t.Error("Expected text format but received: ", format)
+	}
+}
@@ -0,0 +1,211 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const epubMimeType = "application/epub+zip"
+
+// Chapter is a chapter of a book. The summary is empty until the book is summarized
+type Chapter struct {
+	Title   string
+	Text    string
+	Summary string
+}
+
+type epubContainer struct {
+	RootFiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Titles        []string `xml:"metadata>title"`
+	ManifestItems []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		TOC      string `xml:"toc,attr"`
+		ItemRefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+type epubNCX struct {
+	NavPoints []epubNavPoint `xml:"navMap>navPoint"`
+}
+
+type epubNavPoint struct {
+	Label     string         `xml:"navLabel>text"`
+	Source    epubNavSource  `xml:"content"`
+	NavPoints []epubNavPoint `xml:"navPoint"`
+}
+
+type epubNavSource struct {
+	Src string `xml:"src,attr"`
+}
+
+// readEPUBDocument reads the chapters of the book in the reading order of the spine.
+// The chapter titles come from the table of contents or from the chapter headings
+func readEPUBDocument(data []byte) (*Document, error) {
+	var archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	containerContent, err := readZipFile(archive, "META-INF/container.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var container epubContainer
+	err = xml.Unmarshal(containerContent, &container)
+	if err != nil {
+		return nil, err
+	}
+	if len(container.RootFiles) == 0 {
+		return nil, errors.New("The epub container has no package document")
+	}
+
+	var packagePath = container.RootFiles[0].FullPath
+	packageContent, err := readZipFile(archive, packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var book epubPackage
+	err = xml.Unmarshal(packageContent, &book)
+	if err != nil {
+		return nil, err
+	}
+
+	var packageDirectory = path.Dir(packagePath)
+	var hrefs = make(map[string]string)
+	var tocTitles = make(map[string]string)
+	for _, item := range book.ManifestItems {
+		var itemPath = resolveEPUBPath(packageDirectory, item.Href)
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			hrefs[item.ID] = itemPath
+		}
+
+		var isNav = strings.Contains(" "+item.Properties+" ", " nav ")
+		if isNav || item.ID == book.Spine.TOC {
+			if tocContent, err := readZipFile(archive, itemPath); err == nil {
+				readEPUBTableOfContents(tocContent, path.Dir(itemPath), isNav, tocTitles)
+			}
+		}
+	}
+
+	var document = new(Document)
+	if len(book.Titles) > 0 {
+		document.Title = collapseSpaces(book.Titles[0])
+	}
+
+	for _, itemRef := range book.Spine.ItemRefs {
+		// Non-linear items like footnotes and answers are outside of the reading order
+		var chapterPath, exists = hrefs[itemRef.IDRef]
+		if !exists || itemRef.Linear == "no" {
+			continue
+		}
+
+		chapterContent, err := readZipFile(archive, chapterPath)
+		if err != nil {
+			continue
+		}
+
+		title, text, _, err := ExtractMainInfoFromHTML(string(chapterContent))
+		if err != nil || strings.TrimSpace(text) == "" {
+			// Covers and title pages have no text worth summarizing
+			continue
+		}
+
+		if tocTitle := tocTitles[chapterPath]; tocTitle != "" {
+			title = tocTitle
+		}
+
+		document.Chapters = append(document.Chapters, Chapter{Title: title, Text: strings.TrimSpace(text)})
+	}
+
+	if len(document.Chapters) == 0 {
+		return nil, errors.New("The epub document has no chapters with text")
+	}
+
+	var texts = []string{}
+	for _, chapter := range document.Chapters {
+		texts = append(texts, chapter.Text)
+	}
+	document.Text = strings.Join(texts, "\n\n")
+
+	return document, nil
+}
+
+// resolveEPUBPath resolves the href relative to the directory inside the archive, removing the fragment
+func resolveEPUBPath(directory string, href string) string {
+	if fragment := strings.Index(href, "#"); fragment >= 0 {
+		href = href[:fragment]
+	}
+
+	if unescapedHref, err := url.PathUnescape(href); err == nil {
+		href = unescapedHref
+	}
+
+	return strings.TrimPrefix(path.Join(directory, href), "./")
+}
+
+// readEPUBTableOfContents maps the chapter paths to their titles from the EPUB 3 navigation document
+// or the EPUB 2 NCX file. Only the first title of every chapter is kept
+func readEPUBTableOfContents(content []byte, directory string, isNav bool, titles map[string]string) {
+	var addTitle = func(href string, title string) {
+		var chapterPath = resolveEPUBPath(directory, href)
+		title = collapseSpaces(title)
+		if title != "" && titles[chapterPath] == "" {
+			titles[chapterPath] = title
+		}
+	}
+
+	if !isNav {
+		var ncx epubNCX
+		if xml.Unmarshal(content, &ncx) == nil {
+			var addNavPoints func(navPoints []epubNavPoint)
+			addNavPoints = func(navPoints []epubNavPoint) {
+				for _, navPoint := range navPoints {
+					addTitle(navPoint.Source.Src, navPoint.Label)
+					addNavPoints(navPoint.NavPoints)
+				}
+			}
+			addNavPoints(ncx.NavPoints)
+		}
+		return
+	}
+
+	var doc, err = html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+
+	for _, nav := range extractNodes(doc, "nav") {
+		var navType, _ = getAttribute(nav, "epub:type")
+		if navType != "" && navType != "toc" {
+			continue
+		}
+
+		for _, link := range extractNodes(nav, "a") {
+			if href, hasHref := getAttribute(link, "href"); hasHref {
+				addTitle(href, extractTextFromNode(link))
+			}
+		}
+	}
+}
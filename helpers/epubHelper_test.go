@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func createTestEPUB() []byte {
+	var container = `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`
+	var opf = `<?xml version="1.0"?><package xmlns="http://www.idpf.org/2007/opf" version="3.0"><metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Test book</dc:title></metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+<item id="second" href="text/chapter%202.xhtml" media-type="application/xhtml+xml"/>
+<item id="first" href="text/chapter1.xhtml" media-type="application/xhtml+xml"/>
+<item id="notes" href="text/notes.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine><itemref idref="cover"/><itemref idref="first"/><itemref idref="second"/><itemref idref="notes" linear="no"/></spine></package>`
+	var nav = `<html xmlns:epub="http://www.idpf.org/2007/ops"><body><nav epub:type="toc"><ol><li><a href="text/chapter1.xhtml">The beginning</a></li><li><a href="text/chapter%202.xhtml#start">The end</a></li></ol></nav></body></html>`
+	var chapter = func(text string) string {
+		return `<html><body><div><p>` + text + ` The first sentence of the chapter. The second sentence of the chapter.</p></div></body></html>`
+	}
+
+	return createTestArchive(
+		"mimetype", "application/epub+zip",
+		"META-INF/container.xml", container,
+		"OEBPS/content.opf", opf,
+		"OEBPS/nav.xhtml", nav,
+		"OEBPS/cover.xhtml", `<html><body><img src="cover.jpg"/></body></html>`,
+		"OEBPS/text/chapter1.xhtml", chapter("Once upon a time."),
+		"OEBPS/text/chapter 2.xhtml", chapter("The story ends."),
+		"OEBPS/text/notes.xhtml", chapter("A note."),
+	)
+}
+
+func TestReadingEPUBDocument(t *testing.T) {
+	var data = createTestEPUB()
+	if format := DetectFormat("book", data); format != FormatEPUB {
+		t.Error("Expected epub format but received: ", format)
+	}
+
+	var document, err = ReadDocument(data, FormatEPUB, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Test book" {
+		t.Error("Expected the book title but received: ", document.Title)
+	}
+
+	if len(document.Chapters) != 2 {
+		t.Fatal("Expected the two linear chapters with text but received: ", document.Chapters)
+	}
+
+	if document.Chapters[0].Title != "The beginning" || document.Chapters[1].Title != "The end" {
+		t.Error("Expected the titles from the table of contents but received: ", document.Chapters)
+	}
+
+	if document.Text != document.Chapters[0].Text+"\n\n"+document.Chapters[1].Text {
+		t.Error("Expected the chapters text in the spine order but received: ", document.Text)
+	}
+}
+
+func TestReadingEPUBWithoutPackage(t *testing.T) {
+	var data = createTestArchive("mimetype", "application/epub+zip", "META-INF/container.xml", "<container><rootfiles></rootfiles></container>")
+	var _, err = ReadDocument(data, FormatEPUB, "")
+	if err == nil {
+		t.Error("Expected error for epub without package document")
+	}
+}
@@ -0,0 +1,360 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EPUBRenderer writes summaries as EPUB 3 book with one chapter per summary, a table of contents
+// and the metadata of the book. The images of every summary are downloaded with the fetcher or with DefaultFetcher
+// if it's nil and embedded in the book. MaxImages, the limit per summary, ImageDir and AllowLocalImages work
+// like in ImageOptions. Downloading is aborted when the context is done.
+// The title, author and language of the book default to the ones of the first summary, the identifier to
+// an uuid made of the sources of the summaries and the modification time to the current time
+type EPUBRenderer struct {
+	Context    context.Context
+	Fetcher    Fetcher
+	Title      string
+	Author     string
+	Language   string
+	Identifier string
+	Modified   time.Time
+
+	MaxImages        int
+	ImageDir         string
+	AllowLocalImages bool
+}
+
+// epubChapter is a summary written as chapter of the book
+type epubChapter struct {
+	summary *Summary
+	title   string
+	images  []embeddedImage
+}
+
+const epubContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>`
+
+const epubStyle = `body { font-family: serif; line-height: 1.5; margin: 0 5%; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; }
+.source { font-size: 0.85em; word-wrap: break-word; }
+dl.metadata { font-size: 0.85em; color: #555; }
+dl.metadata dt { font-weight: bold; float: left; margin-right: 0.5em; }
+figure { margin: 1.5em 0; text-align: center; }
+figure img { max-width: 100%; }
+figcaption { font-size: 0.8em; color: #555; word-wrap: break-word; }
+.keywords { font-size: 0.85em; font-style: italic; }
+`
+
+// Render writes the summary as book with a single chapter
+func (r EPUBRenderer) Render(w io.Writer, summary *Summary) error {
+	return r.RenderCollection(w, []*Summary{summary})
+}
+
+// RenderCollection writes the summaries as book with one chapter per summary, in the given order
+func (r EPUBRenderer) RenderCollection(w io.Writer, summaries []*Summary) error {
+	if len(summaries) == 0 {
+		return errors.New("At least one summary is needed for an EPUB book")
+	}
+
+	var ctx = r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var chapters = []epubChapter{}
+	for i, summary := range summaries {
+		var images, err = loadEmbeddedImages(ctx, r.Fetcher, summary.Images, ImageOptions{MaxImages: r.MaxImages, ImageDir: r.ImageDir, AllowLocalImages: r.AllowLocalImages})
+		if err != nil {
+			return err
+		}
+
+		var title = strings.TrimSpace(summary.Title)
+		if title == "" {
+			title = "Article " + strconv.Itoa(i+1)
+		}
+		chapters = append(chapters, epubChapter{summary: summary, title: title, images: images})
+	}
+
+	var book = r.bookMetadata(summaries)
+	var archive = zip.NewWriter(w)
+
+	// The mimetype must be the first file of the archive and it must not be compressed
+	var mimeTypeWriter, err = archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(mimeTypeWriter, epubMimeType); err != nil {
+		return err
+	}
+
+	var files = []struct {
+		name    string
+		content []byte
+	}{
+		{"META-INF/container.xml", []byte(epubContainerXML)},
+		{"OEBPS/content.opf", buildEPUBPackage(book, chapters)},
+		{"OEBPS/nav.xhtml", buildEPUBNavigation(book, chapters)},
+		{"OEBPS/toc.ncx", buildEPUBNCX(book, chapters)},
+		{"OEBPS/style.css", []byte(epubStyle)},
+	}
+	for i, chapter := range chapters {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{"OEBPS/" + epubChapterFileName(i), buildEPUBChapter(book, chapter, i)})
+
+		for j, image := range chapter.images {
+			files = append(files, struct {
+				name    string
+				content []byte
+			}{"OEBPS/" + epubImageFileName(i, j, image), image.data})
+		}
+	}
+
+	for _, file := range files {
+		var fileWriter, err = archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = fileWriter.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// bookMetadata returns the renderer with the defaults for the empty metadata
+func (r EPUBRenderer) bookMetadata(summaries []*Summary) EPUBRenderer {
+	var book = r
+	var first = summaries[0]
+	if book.Title == "" {
+		book.Title = first.Title
+	}
+	if book.Title == "" {
+		book.Title = "Summaries"
+	}
+	if book.Author == "" {
+		book.Author = first.Metadata[MetadataAuthor]
+	}
+	if book.Language == "" {
+		book.Language = first.Metadata[MetadataLanguage]
+	}
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Identifier == "" {
+		var sources = []string{}
+		for _, summary := range summaries {
+			sources = append(sources, summary.SourceURL+"\n"+summary.Title)
+		}
+		book.Identifier = "urn:uuid:" + nameBasedUUID(strings.Join(sources, "\n"))
+	}
+	if book.Modified.IsZero() {
+		book.Modified = time.Now()
+	}
+	return book
+}
+
+// nameBasedUUID returns a version 5 like uuid made of the sha1 hash of the name,
+// so the same summaries always give the same book identifier
+func nameBasedUUID(name string) string {
+	var hash = sha1.Sum([]byte(name))
+	hash[6] = (hash[6] & 0x0f) | 0x50
+	hash[8] = (hash[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
+
+func epubChapterFileName(chapter int) string {
+	return "chapter-" + strconv.Itoa(chapter+1) + ".xhtml"
+}
+
+func epubImageFileName(chapter int, number int, image embeddedImage) string {
+	return "images/chapter-" + strconv.Itoa(chapter+1) + "-" + image.fileName(number+1)
+}
+
+func buildEPUBPackage(book EPUBRenderer, chapters []epubChapter) []byte {
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + escapeXMLText(book.Language) + `">` + "\n")
+	content.WriteString(`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	content.WriteString(`<dc:identifier id="book-id">` + escapeXMLText(book.Identifier) + "</dc:identifier>\n")
+	content.WriteString("<dc:title>" + escapeXMLText(book.Title) + "</dc:title>\n")
+	content.WriteString("<dc:language>" + escapeXMLText(book.Language) + "</dc:language>\n")
+	if book.Author != "" {
+		content.WriteString("<dc:creator>" + escapeXMLText(book.Author) + "</dc:creator>\n")
+	}
+	for _, chapter := range chapters {
+		if chapter.summary.SourceURL != "" {
+			content.WriteString("<dc:source>" + escapeXMLText(chapter.summary.SourceURL) + "</dc:source>\n")
+		}
+	}
+	content.WriteString(`<meta property="dcterms:modified">` + book.Modified.UTC().Format("2006-01-02T15:04:05Z") + "</meta>\n")
+	content.WriteString("</metadata>\n")
+
+	content.WriteString("<manifest>\n")
+	content.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	content.WriteString(`<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>` + "\n")
+	content.WriteString(`<item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	for i, chapter := range chapters {
+		content.WriteString(`<item id="chapter-` + strconv.Itoa(i+1) + `" href="` + epubChapterFileName(i) + `" media-type="application/xhtml+xml"/>` + "\n")
+		for j, image := range chapter.images {
+			content.WriteString(`<item id="image-` + strconv.Itoa(i+1) + "-" + strconv.Itoa(j+1) + `" href="` + epubImageFileName(i, j, image) +
+				`" media-type="image/` + image.format + `"/>` + "\n")
+		}
+	}
+	content.WriteString("</manifest>\n")
+
+	content.WriteString(`<spine toc="ncx">` + "\n")
+	for i := range chapters {
+		content.WriteString(`<itemref idref="chapter-` + strconv.Itoa(i+1) + `"/>` + "\n")
+	}
+	content.WriteString("</spine>\n</package>")
+	return content.Bytes()
+}
+
+// buildEPUBNavigation returns the table of contents with the articles and their chapter summaries
+func buildEPUBNavigation(book EPUBRenderer, chapters []epubChapter) []byte {
+	var content bytes.Buffer
+	writeEPUBDocumentStart(&content, book, "Contents")
+	content.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>Contents</h1>\n<ol>\n")
+	for i, chapter := range chapters {
+		content.WriteString(`<li><a href="` + epubChapterFileName(i) + `">` + escapeXMLText(chapter.title) + "</a>")
+
+		var sections = getEPUBSections(chapter.summary)
+		if len(sections) > 0 {
+			content.WriteString("\n<ol>\n")
+			for j, section := range sections {
+				content.WriteString(`<li><a href="` + epubChapterFileName(i) + "#section-" + strconv.Itoa(j+1) + `">` + escapeXMLText(section.Title) + "</a></li>\n")
+			}
+			content.WriteString("</ol>\n")
+		}
+		content.WriteString("</li>\n")
+	}
+	content.WriteString("</ol>\n</nav>\n</body>\n</html>")
+	return content.Bytes()
+}
+
+// buildEPUBNCX returns the table of contents for the EPUB 2 readers
+func buildEPUBNCX(book EPUBRenderer, chapters []epubChapter) []byte {
+	var content bytes.Buffer
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">` + "\n")
+	content.WriteString(`<head><meta name="dtb:uid" content="` + escapeXMLText(book.Identifier) + `"/></head>` + "\n")
+	content.WriteString("<docTitle><text>" + escapeXMLText(book.Title) + "</text></docTitle>\n<navMap>\n")
+	for i, chapter := range chapters {
+		content.WriteString(`<navPoint id="nav-` + strconv.Itoa(i+1) + `" playOrder="` + strconv.Itoa(i+1) + `">`)
+		content.WriteString("<navLabel><text>" + escapeXMLText(chapter.title) + "</text></navLabel>")
+		content.WriteString(`<content src="` + epubChapterFileName(i) + `"/></navPoint>` + "\n")
+	}
+	content.WriteString("</navMap>\n</ncx>")
+	return content.Bytes()
+}
+
+// buildEPUBChapter returns the chapter with the title, the source, linked if it's a web page, the metadata,
+// the summary sentences as paragraphs, the chapter summaries, the keywords and the images of the summary
+func buildEPUBChapter(book EPUBRenderer, chapter epubChapter, number int) []byte {
+	var summary = chapter.summary
+	var content bytes.Buffer
+	writeEPUBDocumentStart(&content, book, chapter.title)
+	content.WriteString(`<section epub:type="chapter">` + "\n")
+	content.WriteString("<h1>" + escapeXMLText(chapter.title) + "</h1>\n")
+	if isWebURL(summary.SourceURL) {
+		content.WriteString(`<p class="source"><a href="` + escapeXMLText(summary.SourceURL) + `">` + escapeXMLText(summary.SourceURL) + "</a></p>\n")
+	} else if summary.SourceURL != "" {
+		content.WriteString(`<p class="source">` + escapeXMLText(summary.SourceURL) + "</p>\n")
+	}
+
+	var keys = make([]string, 0, len(summary.Metadata))
+	for key := range summary.Metadata {
+		if summary.Metadata[key] != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		content.WriteString(`<dl class="metadata">` + "\n")
+		for _, key := range keys {
+			content.WriteString("<dt>" + escapeXMLText(metadataLabel(key)) + "</dt><dd>" + escapeXMLText(summary.Metadata[key]) + "</dd>\n")
+		}
+		content.WriteString("</dl>\n")
+	}
+
+	writeEPUBSentences(&content, summary.SentenceTexts())
+
+	for i, section := range getEPUBSections(summary) {
+		content.WriteString(`<h2 id="section-` + strconv.Itoa(i+1) + `">` + escapeXMLText(section.Title) + "</h2>\n")
+		writeEPUBSentences(&content, strings.Split(section.Summary, "\n"))
+	}
+
+	if len(summary.Keywords) > 0 {
+		content.WriteString(`<p class="keywords">` + escapeXMLText(strings.Join(summary.Keywords, ", ")) + "</p>\n")
+	}
+
+	for i, image := range chapter.images {
+		var figure = "Figure " + strconv.Itoa(i+1) + ": " + image.source
+		content.WriteString(`<figure><img src="` + epubImageFileName(number, i, image) + `" alt="` + escapeXMLText(figure) + `"/>`)
+		content.WriteString("<figcaption>" + escapeXMLText(figure) + "</figcaption></figure>\n")
+	}
+
+	content.WriteString("</section>\n</body>\n</html>")
+	return content.Bytes()
+}
+
+func writeEPUBDocumentStart(content *bytes.Buffer, book EPUBRenderer, title string) {
+	var language = escapeXMLText(book.Language)
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<!DOCTYPE html>\n")
+	content.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + language + `" lang="` + language + `">` + "\n")
+	content.WriteString("<head>\n<title>" + escapeXMLText(title) + "</title>\n")
+	content.WriteString(`<link rel="stylesheet" type="text/css" href="style.css"/>` + "\n</head>\n<body>\n")
+}
+
+func writeEPUBSentences(content *bytes.Buffer, sentences []string) {
+	for _, sentence := range sentences {
+		if strings.TrimSpace(sentence) != "" {
+			content.WriteString("<p>" + escapeXMLText(strings.TrimSpace(sentence)) + "</p>\n")
+		}
+	}
+}
+
+// getEPUBSections returns the chapters of the summary, which have a title and a summary
+func getEPUBSections(summary *Summary) []Chapter {
+	var sections = []Chapter{}
+	for _, chapter := range summary.Chapters {
+		if strings.TrimSpace(chapter.Title) != "" && chapter.Summary != "" {
+			sections = append(sections, chapter)
+		}
+	}
+	return sections
+}
+
+// StoreSummariesToEPUB stores the summaries as EPUB book with one chapter per summary to the file from the given path
+func StoreSummariesToEPUB(path string, summaries []*Summary, renderer EPUBRenderer, mode WriteMode) (bool, error) {
+	if mode == WriteAppend {
+		return false, errors.New("Only txt and md files can be appended to")
+	}
+
+	var content bytes.Buffer
+	var err = renderer.RenderCollection(&content, summaries)
+	if err != nil {
+		return false, err
+	}
+
+	err = writeFileAtomically(path, content.Bytes(), mode)
+	return err == nil, err
+}
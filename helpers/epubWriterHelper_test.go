@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderingEPUBCollection(t *testing.T) {
+	var encodePNG = func(content *bytes.Buffer, picture image.Image) error { return png.Encode(content, picture) }
+	var fetcher = mapFetcher{"https://example.com/river.png": createTestImage(t, 200, 100, encodePNG)}
+
+	var summaries = []*Summary{
+		{
+			Title:     "Mountains & rivers",
+			SourceURL: "https://example.com/rivers?page=1&lang=en",
+			Metadata:  map[string]string{MetadataAuthor: "Jane Doe"},
+			Sentences: []SummarySentence{{Text: "The river flows to the sea."}, {Text: "The mountains are high."}},
+			Images:    []string{"https://example.com/river.png"},
+		},
+		{
+			SourceURL: "javascript:alert(1)",
+			Sentences: []SummarySentence{{Text: "The second article has no title."}},
+			Chapters:  []Chapter{{Title: "Part one", Summary: "The first part is short."}},
+		},
+	}
+
+	var renderer = EPUBRenderer{Fetcher: fetcher, Title: "Weekly <digest>", MaxImages: 2, Modified: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	var content bytes.Buffer
+	if err := renderer.RenderCollection(&content, summaries); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content.Bytes()), int64(content.Len()))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if archive.File[0].Name != "mimetype" || archive.File[0].Method != zip.Store {
+		t.Error("Expected uncompressed mimetype as first file but received: ", archive.File[0].Name)
+	}
+
+	var files = map[string]string{}
+	for _, file := range archive.File {
+		var reader, err = file.Open()
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		files[file.Name] = string(data)
+
+		if !strings.HasSuffix(file.Name, ".png") && !strings.HasSuffix(file.Name, ".css") && file.Name != "mimetype" {
+			var decoder = xml.NewDecoder(bytes.NewReader(data))
+			for {
+				if _, err := decoder.Token(); err != nil {
+					if err != io.EOF {
+						t.Error("Expected well-formed xml in "+file.Name+" but received: ", err.Error())
+					}
+					break
+				}
+			}
+		}
+	}
+
+	if files["mimetype"] != "application/epub+zip" {
+		t.Error("Expected epub mimetype but received: ", files["mimetype"])
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "OEBPS/") && name != "OEBPS/content.opf" &&
+			!strings.Contains(files["OEBPS/content.opf"], `href="`+strings.TrimPrefix(name, "OEBPS/")+`"`) {
+			t.Error("Expected the manifest to contain: ", name)
+		}
+	}
+	if _, found := files["OEBPS/images/chapter-1-image1.png"]; !found {
+		t.Error("Expected the image of the first article to be embedded")
+	}
+	for _, text := range []string{`<dc:title>Weekly &lt;digest&gt;</dc:title>`, `<dc:creator>Jane Doe</dc:creator>`, `<meta property="dcterms:modified">2024-03-01T10:00:00Z</meta>`, `<dc:identifier id="book-id">urn:uuid:`} {
+		if !strings.Contains(files["OEBPS/content.opf"], text) {
+			t.Error("Expected the package to contain '"+text+"' but received: ", files["OEBPS/content.opf"])
+		}
+	}
+	if !strings.Contains(files["OEBPS/chapter-1.xhtml"], `<p class="source"><a href="https://example.com/rivers?page=1&amp;lang=en">`) {
+		t.Error("Expected a link to the web page source but received: ", files["OEBPS/chapter-1.xhtml"])
+	}
+	if strings.Contains(files["OEBPS/chapter-2.xhtml"], `href="javascript:`) || !strings.Contains(files["OEBPS/chapter-2.xhtml"], `<p class="source">javascript:alert(1)</p>`) {
+		t.Error("Expected the other source as plain text but received: ", files["OEBPS/chapter-2.xhtml"])
+	}
+	if !strings.Contains(files["OEBPS/nav.xhtml"], `<a href="chapter-2.xhtml#section-1">Part one</a>`) {
+		t.Error("Expected the navigation to link the chapter summary but received: ", files["OEBPS/nav.xhtml"])
+	}
+
+	document, err := ReadDocument(content.Bytes(), FormatEPUB, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if document.Title != "Weekly <digest>" {
+		t.Error("Expected title 'Weekly <digest>' but received: ", document.Title)
+	}
+	if len(document.Chapters) != 2 || document.Chapters[0].Title != "Mountains & rivers" || document.Chapters[1].Title != "Article 2" {
+		t.Fatal("Expected chapters 'Mountains & rivers' and 'Article 2' but received: ", document.Chapters)
+	}
+	if !strings.Contains(document.Chapters[0].Text, "The river flows to the sea.") {
+		t.Error("Expected the first chapter to contain the summary but received: ", document.Chapters[0].Text)
+	}
+}
+
+func TestRenderingEPUBWithoutSummaries(t *testing.T) {
+	var content bytes.Buffer
+	if err := (EPUBRenderer{}).RenderCollection(&content, nil); err == nil {
+		t.Error("Expected error for a book without summaries but received none")
+	}
+}
@@ -0,0 +1,396 @@
+package helpers
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent header sent by HTTPFetcher when none is configured
+const DefaultUserAgent = "Mozilla/5.0 (compatible; go-summarizer/1.0; +https://github.com/ktodorov/go-summarizer)"
+
+// DefaultRobotsToken is the product token of the summarizer in robots.txt files
+const DefaultRobotsToken = "go-summarizer"
+
+// Fetcher downloads the content behind an url, e.g. web pages and images
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// HTTPFetcher is the default Fetcher implementation.
+// It supports timeouts, custom headers and cookies, retries, compressed responses,
+// robots.txt rules and per host concurrency and rate limits
+type HTTPFetcher struct {
+	// Client is the http client used for the requests. http.DefaultClient is used if nil
+	Client *http.Client
+	// Timeout limits every single request attempt. Zero means no timeout
+	Timeout time.Duration
+	// UserAgent is sent with every request. DefaultUserAgent is used if empty
+	UserAgent string
+	// Headers are additional headers sent with every request
+	Headers http.Header
+	// Cookies are sent with every request
+	Cookies []*http.Cookie
+	// MaxRetries is the number of retries after a 5xx or 429 response
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry. It doubles after every attempt
+	RetryBackoff time.Duration
+	// MaxRetryWait caps the wait between retries, including the one requested by Retry-After
+	MaxRetryWait time.Duration
+	// MaxBodySize is the maximum size of a decompressed response body. Zero or below means no limit
+	MaxBodySize int64
+	// RespectRobots makes the fetcher check the host robots.txt before every request
+	// and wait at least its Crawl-delay between requests to the same host
+	RespectRobots bool
+	// RobotsToken is the product token matched against the robots.txt user-agent groups. The first
+	// product of UserAgent is used if empty and DefaultRobotsToken for the default User-Agent
+	RobotsToken string
+	// MaxConnsPerHost limits the concurrent requests to a single host. Zero means no limit
+	MaxConnsPerHost int
+	// HostInterval is the minimum time between the starts of two requests to the same host
+	HostInterval time.Duration
+	// Cache keeps the response bodies with their ETag and Last-Modified validators.
+	// Cached urls are requested conditionally and not downloaded again if they are unchanged
+	Cache Cache
+
+	// robots and hosts are shared between all requests of the fetcher, so the same
+	// fetcher instance should be used for all urls that must be fetched politely
+	stateMutex sync.Mutex
+	robots     *robotsCache
+	hosts      *hostLimiter
+}
+
+// HTTPStatusError is returned when the server responds with a non successful status code
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	retryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "Unexpected response status " + strconv.Itoa(e.StatusCode) + " from " + e.URL
+}
+
+// NewHTTPFetcher creates HTTPFetcher instance with the default settings
+func NewHTTPFetcher() *HTTPFetcher {
+	var fetcher = new(HTTPFetcher)
+	fetcher.Timeout = 30 * time.Second
+	fetcher.UserAgent = DefaultUserAgent
+	fetcher.MaxRetries = 2
+	fetcher.RetryBackoff = 500 * time.Millisecond
+	fetcher.MaxRetryWait = 30 * time.Second
+	fetcher.MaxBodySize = MaxBodySize
+	return fetcher
+}
+
+// DefaultFetcher is used when no fetcher is given. It's shared by all requests, so its robots.txt cache and
+// per host limits apply to all of them. Change its settings before the first request, e.g. to respect robots.txt
+var DefaultFetcher = NewHTTPFetcher()
+
+// getFetcher returns the given fetcher or DefaultFetcher if it's missing
+func getFetcher(fetcher Fetcher) Fetcher {
+	if fetcher == nil {
+		return DefaultFetcher
+	}
+
+	return fetcher
+}
+
+// Fetch downloads the body behind the url, retrying on 5xx and 429 responses.
+// The robots.txt rules and the per host limits are applied before every attempt
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var interval = f.HostInterval
+	if f.RespectRobots {
+		group, err := f.getRobots().groupFor(ctx, f, pageURL, f.robotsToken())
+		if err != nil {
+			return nil, err
+		}
+
+		if !group.isAllowed(pageURL.EscapedPath() + queryPart(pageURL)) {
+			return nil, &RobotsDisallowedError{URL: rawURL}
+		}
+
+		if group.crawlDelay > interval {
+			interval = group.crawlDelay
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		release, err := f.getHosts().acquire(ctx, pageURL.Host, f.MaxConnsPerHost, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := f.fetchOnce(ctx, rawURL)
+		release()
+		if err == nil {
+			return body, nil
+		}
+
+		var statusError, isStatusError = err.(*HTTPStatusError)
+		if !isStatusError || !isRetryableStatus(statusError.StatusCode) || attempt >= f.MaxRetries {
+			return nil, err
+		}
+
+		var timer = time.NewTimer(f.retryWait(attempt, statusError.retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (f *HTTPFetcher) getRobots() *robotsCache {
+	f.stateMutex.Lock()
+	defer f.stateMutex.Unlock()
+
+	if f.robots == nil {
+		f.robots = newRobotsCache()
+	}
+
+	return f.robots
+}
+
+func (f *HTTPFetcher) getHosts() *hostLimiter {
+	f.stateMutex.Lock()
+	defer f.stateMutex.Unlock()
+
+	if f.hosts == nil {
+		f.hosts = newHostLimiter()
+	}
+
+	return f.hosts
+}
+
+func (f *HTTPFetcher) userAgent() string {
+	if f.UserAgent == "" {
+		return DefaultUserAgent
+	}
+
+	return f.UserAgent
+}
+
+// robotsToken returns the product token for the robots.txt groups, which is the user agent up to the version or the comment
+func (f *HTTPFetcher) robotsToken() string {
+	if f.RobotsToken != "" {
+		return f.RobotsToken
+	}
+	if f.userAgent() == DefaultUserAgent {
+		return DefaultRobotsToken
+	}
+
+	var userAgent = strings.TrimSpace(f.userAgent())
+	if end := strings.IndexAny(userAgent, "/ ("); end >= 0 {
+		return userAgent[:end]
+	}
+	return userAgent
+}
+
+func queryPart(pageURL *url.URL) string {
+	if pageURL.RawQuery == "" {
+		return ""
+	}
+
+	return "?" + pageURL.RawQuery
+}
+
+func (f *HTTPFetcher) fetchOnce(ctx context.Context, rawURL string) ([]byte, error) {
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	request, err := f.newRequest(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var cachedEntry *CacheEntry
+	if f.Cache != nil {
+		cachedEntry, _ = f.Cache.Get(rawURL)
+		addValidators(request, cachedEntry)
+	}
+
+	var client = f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && cachedEntry != nil {
+		return cachedEntry.Body, nil
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, &HTTPStatusError{
+			URL:        rawURL,
+			StatusCode: response.StatusCode,
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	bodyReader, err := decodeBody(response)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyReader.Close()
+
+	body, err := readFromReader(bodyReader, f.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Cache != nil {
+		f.storeInCache(rawURL, response, body)
+	}
+
+	return body, nil
+}
+
+// addValidators makes the request conditional if there is a cached entry with validators
+func addValidators(request *http.Request, cachedEntry *CacheEntry) {
+	if cachedEntry == nil {
+		return
+	}
+
+	if cachedEntry.ETag != "" {
+		request.Header.Set("If-None-Match", cachedEntry.ETag)
+	}
+
+	if cachedEntry.LastModified != "" {
+		request.Header.Set("If-Modified-Since", cachedEntry.LastModified)
+	}
+}
+
+// storeInCache stores the body if the response has validators for revalidating it later
+func (f *HTTPFetcher) storeInCache(rawURL string, response *http.Response, body []byte) {
+	var entry = &CacheEntry{
+		Body:         body,
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+	}
+
+	if entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+
+	if strings.Contains(strings.ToLower(response.Header.Get("Cache-Control")), "no-store") {
+		return
+	}
+
+	// The cache is an optimization, so failing to store an entry is not an error for the caller
+	f.Cache.Set(rawURL, entry)
+}
+
+func (f *HTTPFetcher) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	request, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+
+	for name, values := range f.Headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
+	request.Header.Set("User-Agent", f.userAgent())
+
+	// Setting Accept-Encoding ourselves disables the transparent decompression
+	// of the transport, so decodeBody takes care of it
+	request.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	for _, cookie := range f.Cookies {
+		request.AddCookie(cookie)
+	}
+
+	return request, nil
+}
+
+func (f *HTTPFetcher) retryWait(attempt int, retryAfter time.Duration) time.Duration {
+	var wait = f.RetryBackoff << uint(attempt)
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+
+	if f.MaxRetryWait > 0 && wait > f.MaxRetryWait {
+		wait = f.MaxRetryWait
+	}
+
+	return wait
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// parseRetryAfter reads the Retry-After header value, which is either
+// a number of seconds or an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// decodeBody returns a reader for the response body, decompressing it if needed
+func decodeBody(response *http.Response) (io.ReadCloser, error) {
+	var encoding = strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(response.Body)
+	case "deflate":
+		// "deflate" should be zlib wrapped, but some servers send raw deflate data
+		var bufferedBody = bufio.NewReader(response.Body)
+		header, err := bufferedBody.Peek(2)
+		if err == nil && isZlibHeader(header) {
+			return zlib.NewReader(bufferedBody)
+		}
+
+		return flate.NewReader(bufferedBody), nil
+	}
+
+	return io.NopCloser(response.Body), nil
+}
+
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
@@ -0,0 +1,216 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func createTestFetcher() *HTTPFetcher {
+	var fetcher = NewHTTPFetcher()
+	fetcher.RetryBackoff = time.Millisecond
+	return fetcher
+}
+
+func TestFetchingSendsConfiguredHeaders(t *testing.T) {
+	var receivedRequest *http.Request
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.UserAgent = "test-agent"
+	fetcher.Headers = http.Header{"X-Test": []string{"test value"}}
+	fetcher.Cookies = []*http.Cookie{{Name: "session", Value: "123"}}
+
+	var _, err = fetcher.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if receivedRequest.UserAgent() != "test-agent" {
+		t.Error("Expected 'test-agent' user agent but received: ", receivedRequest.UserAgent())
+	}
+
+	if receivedRequest.Header.Get("X-Test") != "test value" {
+		t.Error("Expected custom header but received: ", receivedRequest.Header.Get("X-Test"))
+	}
+
+	var cookie, cookieErr = receivedRequest.Cookie("session")
+	if cookieErr != nil || cookie.Value != "123" {
+		t.Error("Expected session cookie with value '123' but didn't receive it")
+	}
+}
+
+func TestFetchingGzipResponse(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		var gzipWriter = gzip.NewWriter(w)
+		gzipWriter.Write([]byte("compressed body"))
+		gzipWriter.Close()
+	}))
+	defer server.Close()
+
+	var body, err = createTestFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if string(body) != "compressed body" {
+		t.Error("Expected 'compressed body' but received: ", string(body))
+	}
+}
+
+func TestFetchingDeflateResponse(t *testing.T) {
+	var compressed bytes.Buffer
+	var zlibWriter = zlib.NewWriter(&compressed)
+	zlibWriter.Write([]byte("deflated body"))
+	zlibWriter.Close()
+
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	var body, err = createTestFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if string(body) != "deflated body" {
+		t.Error("Expected 'deflated body' but received: ", string(body))
+	}
+}
+
+func TestFetchingRetriesOnServerErrors(t *testing.T) {
+	var attempts = 0
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if attempts == 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var body, err = createTestFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if string(body) != "ok" || attempts != 3 {
+		t.Error("Expected body 'ok' after 3 attempts but received '", string(body), "' after ", attempts)
+	}
+}
+
+func TestFetchingDoesNotRetryClientErrors(t *testing.T) {
+	var attempts = 0
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var _, err = createTestFetcher().Fetch(context.Background(), server.URL)
+	var statusError *HTTPStatusError
+	if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusNotFound {
+		t.Error("Expected 404 HTTPStatusError but received: ", err)
+	}
+
+	if attempts != 1 {
+		t.Error("Expected 1 attempt but received ", attempts)
+	}
+}
+
+func TestFetchingTimeout(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.Timeout = 10 * time.Millisecond
+
+	var _, err = fetcher.Fetch(context.Background(), server.URL)
+	if err == nil {
+		t.Error("Expected timeout error but received none")
+	}
+}
+
+func TestFetchingWithCancelledContextStopsRetrying(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.RetryBackoff = time.Minute
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var _, err = fetcher.Fetch(ctx, server.URL)
+	if err != context.DeadlineExceeded {
+		t.Error("Expected context.DeadlineExceeded error but received: ", err)
+	}
+}
+
+func TestRetryAfterParsing(t *testing.T) {
+	var now = time.Date(2017, 1, 14, 12, 0, 0, 0, time.UTC)
+
+	if wait := parseRetryAfter("120", now); wait != 2*time.Minute {
+		t.Error("Expected 2m wait but received ", wait)
+	}
+
+	if wait := parseRetryAfter("Sat, 14 Jan 2017 12:00:30 GMT", now); wait != 30*time.Second {
+		t.Error("Expected 30s wait but received ", wait)
+	}
+
+	if wait := parseRetryAfter("invalid", now); wait != 0 {
+		t.Error("Expected no wait but received ", wait)
+	}
+}
+
+func TestFetchingWithoutFetcherSharesHostLimits(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><p>ok</p></body></html>"))
+	}))
+	defer server.Close()
+
+	if getFetcher(nil) != getFetcher(nil) {
+		t.Fatal("Expected the same default fetcher for every call")
+	}
+
+	var interval = DefaultFetcher.HostInterval
+	DefaultFetcher.HostInterval = 100 * time.Millisecond
+	defer func() { DefaultFetcher.HostInterval = interval }()
+
+	var start = time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := GetHTMLFromURL(context.Background(), nil, server.URL); err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Error("Expected the second request to wait for the host interval but received: ", elapsed)
+	}
+}
@@ -1,48 +1,21 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	"image"
+	"io"
 	"io/ioutil"
-	"math/rand"
-	"regexp"
-	"runtime"
+	"sort"
+	"unicode/utf8"
 
 	"path/filepath"
 
 	"os"
 
 	"strings"
-
-	"github.com/signintech/gopdf"
-	"github.com/signintech/gopdf/fontmaker/core"
 )
 
-var supportedFileTypes = []string{"txt", "pdf"}
-
-// io.TempFile
-func getProgramRootPath() (string, error) {
-	_, b, _, _ := runtime.Caller(0)
-	basepath := filepath.Dir(filepath.Dir(b))
-	abspath, err := filepath.Abs(basepath)
-	return abspath, err
-}
-
-var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-func generateRandomFileName(path string, extension string) string {
-	b := make([]rune, 10)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	var fullpath = path + "\\" + string(b) + "." + extension
-	if !fileExists(fullpath) {
-		return fullpath
-	}
-
-	return generateRandomFileName(path, extension)
-}
-
 func fileExists(filePath string) bool {
 	var _, err = os.Stat(filePath)
 
@@ -53,298 +26,209 @@ func fileExists(filePath string) bool {
 	return false
 }
 
+// getFileType returns the extension of the path without the dot
 func getFileType(path string) string {
-	for _, fileType := range supportedFileTypes {
-		var regexString = ".+\\." + fileType
-
-		matched, err := regexp.MatchString(regexString, path)
-		if err == nil && matched {
-			return fileType
-		}
-	}
-	return ""
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
 }
 
-func getImageDimension(imagePath string) (int, int, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return 0, 0, err
-	}
+// WriteMode tells what to do when the file already exists
+type WriteMode int
 
-	image, _, err := image.DecodeConfig(file)
-	if err != nil {
-		return 0, 0, err
-	}
-	return image.Width, image.Height, nil
-}
+// Write modes. WriteOverwrite is the default
+const (
+	WriteOverwrite WriteMode = iota
+	WriteAppend
+	WriteFailIfExists
+)
 
-func getFileExtensionFromURL(url string) (string, bool) {
-	var splitURL = strings.Split(url, ".")
-	var lastElement = splitURL[len(splitURL)-1]
-	if strings.Contains(lastElement, "jpg") {
-		return "jpg", true
-	}
-	if strings.Contains(lastElement, "png") {
-		return "png", true
-	}
-	if strings.Contains(lastElement, "jpeg") {
-		return "jpeg", true
-	}
+// StoreTextToFile stores the title, text and images to the given file path, replacing the file if it exists.
+// Images for pdf files are downloaded with DefaultFetcher
+func StoreTextToFile(path string, title string, text string, images []string) (bool, error) {
+	var summary = &Summary{Title: title, Text: text, Images: images}
+	return StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteOverwrite)
+}
 
-	return "", false
+// StoreTextToFileWithMode stores the summary to the given file path. The mode tells if an existing file
+// is replaced, appended to or left untouched with an error. Only txt and md files can be appended to
+func StoreTextToFileWithMode(ctx context.Context, path string, summary *Summary, fetcher Fetcher, mode WriteMode) (bool, error) {
+	return StoreTextToFileWithOptions(ctx, path, summary, RenderOptions{Fetcher: fetcher}, mode)
 }
 
-//StoreTextToFile stores text to the given file path. Creates the file if it's missing or appends to it
-func StoreTextToFile(path string, title string, text string, images []string) (bool, error) {
+// StoreTextToFileWithOptions stores the summary to the given file path, passing the options to the renderers, which need them
+func StoreTextToFileWithOptions(ctx context.Context, path string, summary *Summary, options RenderOptions, mode WriteMode) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	var fileType = getFileType(path)
-	var titleAsBytes = []byte(title)
-	var textAsBytes = []byte(text)
-	var result = false
-	var err error
-
-	if fileType == "txt" {
-		result, err = saveToTextFile(path, titleAsBytes, textAsBytes)
-	} else if fileType == "pdf" {
-		result, err = saveToPDFFile(path, titleAsBytes, textAsBytes, images)
-	} else {
-		err = errors.New("Invalid file type")
+	var renderer, found = GetRenderer(fileType)
+	if !found {
+		return false, errors.New("Invalid file type")
+	}
+	if mode == WriteFailIfExists && fileExists(path) {
+		return false, errors.New("The file already exists")
+	}
+	if mode == WriteAppend && fileType != "txt" && fileType != "md" {
+		return false, errors.New("Only txt and md files can be appended to")
 	}
 
-	return result, err
+	return StoreRenderedToFile(path, summary, renderWithOptions(ctx, renderer, options), mode)
 }
 
-func saveToTextFile(path string, title []byte, text []byte) (bool, error) {
-	err := ioutil.WriteFile(path, title, 0644)
+// StoreRenderedToFile stores the summary rendered with the renderer, like a SummaryTemplate, to the given file path.
+// The mode tells if an existing file is replaced, appended to or left untouched with an error
+func StoreRenderedToFile(path string, summary *Summary, renderer Renderer, mode WriteMode) (bool, error) {
+	var content bytes.Buffer
+	var err = renderer.Render(&content, summary)
 	if err != nil {
 		return false, err
 	}
 
-	err = ioutil.WriteFile(path, text, 0644)
-	var result = (err == nil)
-	return result, err
+	err = writeFileAtomically(path, content.Bytes(), mode)
+	return err == nil, err
 }
 
-func saveToPDFFile(path string, title []byte, text []byte, imageURLs []string) (bool, error) {
-	pdf := gopdf.GoPdf{}
-	var pageSizeHeight = 841.89
-	var pageSizeWidth = 595.28
-	pdf.Start(gopdf.Config{PageSize: gopdf.Rect{W: pageSizeWidth, H: pageSizeHeight}}) //595.28, 841.89 = A4
-	pdf.AddPage()
-
-	abspath, err := getProgramRootPath()
-	if err != nil {
-		return false, err
+// renderText writes the title, the metadata, the summary and the source url
+func renderText(w io.Writer, summary *Summary) error {
+	var content bytes.Buffer
+	if summary.Title != "" {
+		content.WriteString(summary.Title + "\n")
+		content.WriteString(strings.Repeat("=", utf8.RuneCountInString(summary.Title)) + "\n\n")
 	}
 
-	var fontPath = abspath + "/fonts/OpenSans-Regular.ttf"
-	var fontSize = 12
-	err = pdf.AddTTFFont("OpenSans-Regular", fontPath)
-
-	if err != nil {
-		return false, err
+	var keys = make([]string, 0, len(summary.Metadata))
+	for key := range summary.Metadata {
+		keys = append(keys, key)
 	}
-
-	var imagePaths = []string{}
-	var heightUsed = 0.0
-
-	fontSize = 16
-	heightUsed, err = writeTitleToPDF(&pdf, string(title), heightUsed, fontSize, fontPath, pageSizeWidth, pageSizeHeight)
-	if err != nil {
-		return false, err
+	sort.Strings(keys)
+	for _, key := range keys {
+		content.WriteString(metadataLabel(key) + ": " + summary.Metadata[key] + "\n")
 	}
-
-	for _, imageURL := range imageURLs {
-		var imageExtension, isImage = getFileExtensionFromURL(imageURL)
-		if !isImage {
-			continue
-		}
-
-		var imagePath = generateRandomFileName(abspath, imageExtension)
-		err := saveImageFromURL(imageURL, imagePath)
-		if err != nil {
-			continue
-		}
-
-		// Check if image will leave the page.
-		// If thats the case, add new page and start from 0 there
-		imageWidth, imageHeight, err := getImageDimension(imagePath)
-		if err != nil {
-			continue
-		}
-
-		// Print the image only if its not some kind of icon below 50 px from both sides
-		if imageWidth+imageHeight > 50 {
-			var floatImageHeight = float64(imageHeight)
-			var floatImageWidth = float64(imageWidth)
-			var imageProp = imageWidth / int(pageSizeWidth)
-			floatImageWidth = floatImageWidth / float64(imageProp+1)
-			floatImageHeight = floatImageHeight / float64(imageProp+1)
-
-			if heightUsed+floatImageHeight > pageSizeHeight {
-				pdf.AddPage()
-				heightUsed = 0
-			}
-
-			if len(imagePaths) < 2 {
-				pdf.Image(imagePath, 0, heightUsed, &gopdf.Rect{H: floatImageHeight, W: floatImageWidth}) //print image
-				heightUsed += floatImageHeight
-			}
-		}
-
-		// save image file paths in order to delete them later
-		imagePaths = append(imagePaths, imagePath)
+	if len(keys) > 0 {
+		content.WriteString("\n")
 	}
-	heightUsed += 5
 
-	fontSize = 12
-	_, err = writeBodyToPDF(&pdf, string(text), heightUsed, fontSize, fontPath, pageSizeWidth, pageSizeHeight)
-	if err != nil {
-		return false, err
+	content.WriteString(strings.TrimSpace(summary.Text) + "\n")
+	if summary.SourceURL != "" {
+		content.WriteString("\nSource: " + summary.SourceURL + "\n")
 	}
 
-	pdf.WritePdf(path)
-	deleteFiles(imagePaths) // delete temporary created images
-
-	return true, nil
+	var _, err = w.Write(content.Bytes())
+	return err
 }
 
-func writeTitleToPDF(pdf *gopdf.GoPdf, title string, heightUsed float64, fontSize int, fontPath string, pageSizeWidth float64, pageSizeHeight float64) (float64, error) {
-	if title == "" {
-		return heightUsed, nil
+// writeFileAtomically writes the content to a temporary file in the same directory and renames it to the path,
+// so readers never see a half written file. A replaced file keeps its permissions and an existing file
+// is never replaced in the WriteFailIfExists mode. Appended content is written with appendToFile
+func writeFileAtomically(path string, content []byte, mode WriteMode) error {
+	if mode == WriteAppend {
+		return appendToFile(path, content)
 	}
 
-	var err = pdf.SetFont("OpenSans-Regular", "", fontSize)
-	if err != nil {
-		return heightUsed, err
+	var fileMode os.FileMode = 0644
+	if info, err := os.Stat(path); err == nil {
+		fileMode = info.Mode().Perm()
 	}
 
-	titleHeight, err := calculateTextHeight(fontPath, fontSize)
+	var file, err = ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
 	if err != nil {
-		return heightUsed, err
+		return err
 	}
+	defer os.Remove(file.Name())
 
-	heightUsed, _ = writeTextToPDF(pdf, 5, 0, title, pageSizeWidth, pageSizeHeight, titleHeight)
-	heightUsed += 10 // add padding between header and rest of body
-
-	return heightUsed, nil
-}
-
-func writeBodyToPDF(pdf *gopdf.GoPdf, bodyText string, heightUsed float64, fontSize int, fontPath string, pageSizeWidth float64, pageSizeHeight float64) (float64, error) {
-	var err = pdf.SetFont("OpenSans-Regular", "", fontSize)
-	if err != nil {
-		return heightUsed, err
+	if _, err = file.Write(content); err != nil {
+		file.Close()
+		return err
 	}
-
-	textHeight, err := calculateTextHeight(fontPath, fontSize)
-	if err != nil {
-		return heightUsed, err
+	if err = file.Chmod(fileMode); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
 	}
-	textHeight += 4
 
-	heightUsed, err = writeTextToPDF(pdf, 5, heightUsed, string(bodyText), pageSizeWidth, pageSizeHeight, textHeight)
-	if err != nil {
-		return heightUsed, err
+	if mode == WriteFailIfExists {
+		// Unlike rename, link doesn't replace a file created by someone else in the meantime
+		err = os.Link(file.Name(), path)
+		if os.IsExist(err) {
+			return errors.New("The file already exists")
+		}
+		if err != nil {
+			// Some filesystems, like FAT and some network mounts, don't support hard links
+			return createNewFile(path, content, fileMode)
+		}
+		return nil
 	}
 
-	return heightUsed, nil
+	return os.Rename(file.Name(), path)
 }
 
-func calculateTextHeight(fontPath string, fontSize int) (float64, error) {
-	var parser core.TTFParser
-	var err = parser.Parse(fontPath)
+// createNewFile writes the content to the path, failing if the file already exists
+func createNewFile(path string, content []byte, fileMode os.FileMode) error {
+	var file, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fileMode)
+	if os.IsExist(err) {
+		return errors.New("The file already exists")
+	}
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	//Measure Height
-	//get  CapHeight (https://en.wikipedia.org/wiki/Cap_height)
-	cap := float64(float64(parser.CapHeight()) * 1000.00 / float64(parser.UnitsPerEm()))
-	//convert
-	realHeight := cap * (float64(fontSize) / 1000.0)
-
-	return realHeight, nil
+	if _, err = file.Write(content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
 }
 
-func writeTextToPDF(pdf *gopdf.GoPdf, startX float64, startY float64, text string, pageSizeWidth float64, pageSizeHeight float64, textHeight float64) (float64, error) {
-	var textWords = strings.Split(text, " ")
-	var currentLineText = ""
-	var currentLine = 1.0
-
-	for _, word := range textWords {
-		var wordToUse = word
-		var newLineWord = ""
-		// If there is new line in the current word, then we have something like word1\nword2
-		// Then we split the line after the first word and write the second on the new line
-		if strings.Contains(word, "\n") {
-			var wordsByLines = strings.Split(word, "\n")
-			wordToUse = wordsByLines[0]
-			if len(wordsByLines) > 1 {
-				newLineWord = wordsByLines[1]
-			}
-		}
-
-		var tempLineText = currentLineText + " " + wordToUse
-		var textWidth, err = pdf.MeasureTextWidth(tempLineText)
-		if err != nil {
-			return 0, err
-		}
-
-		// This means that if we add the current word,
-		// the text on the current line will move out of the page,
-		// so we add the current word to a new line and write the current line on the pdf
-		if textWidth > pageSizeWidth-15 {
-			currentLine, startY = writeTextLineToPDF(pdf, startX, startY, textHeight, currentLine, currentLineText, pageSizeHeight, false)
-			currentLineText = wordToUse
-		} else {
-			currentLineText = tempLineText
-		}
-
-		// This means that we split the current word and after the first, we must move to new line
-		if newLineWord != "" {
-			currentLine, startY = writeTextLineToPDF(pdf, startX, startY, textHeight, currentLine, currentLineText, pageSizeHeight, true)
-			currentLineText = newLineWord
-		}
+// appendToFile appends the content to the file with a single write in append mode, so concurrent appends
+// don't lose each other's content. The content is separated from the old one with an empty line.
+// Readers can see a half appended file, unlike the files written by writeFileAtomically
+func appendToFile(path string, content []byte) error {
+	var file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
 	}
 
-	var heightUsed = startY + (textHeight * currentLine)
-	pdf.SetX(startX)
-	pdf.SetY(heightUsed)
-	pdf.Cell(nil, currentLineText)
-
-	heightUsed += textHeight
-	pdf.Br(textHeight)
+	var separator, separatorErr = getAppendSeparator(file)
+	if separatorErr != nil {
+		file.Close()
+		return separatorErr
+	}
 
-	return heightUsed, nil
+	if _, err = file.Write(append(separator, content...)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
-func writeTextLineToPDF(pdf *gopdf.GoPdf, startX float64, startY float64, textHeight float64, currentLine float64, text string, pageSizeHeight float64, newParagraph bool) (newCurrentLine float64, newStartY float64) {
-	pdf.SetX(startX)
-	var currentHeight = startY + (textHeight * currentLine)
-	if currentHeight+textHeight > pageSizeHeight {
-		pdf.AddPage()
-		currentHeight = 5
-		currentLine = 1
-		startY = 5
-	} else {
-		currentLine++
-		if newParagraph {
-			currentLine++
-		}
+// getAppendSeparator returns the new lines needed after the end of the file for an empty line before the appended content
+func getAppendSeparator(file *os.File) ([]byte, error) {
+	var info, err = file.Stat()
+	if err != nil || info.Size() == 0 {
+		return nil, err
 	}
-	pdf.SetY(currentHeight)
-	pdf.Cell(nil, text)
 
-	if newParagraph {
-		pdf.Br(textHeight)
+	var ending = make([]byte, 2)
+	var offset = info.Size() - 2
+	if offset < 0 {
+		ending, offset = ending[1:], 0
+	}
+	if _, err = file.ReadAt(ending, offset); err != nil {
+		return nil, err
 	}
 
-	return currentLine, startY
-}
-
-func deleteFiles(filePaths []string) {
-	for _, filePath := range filePaths {
-		if fileExists(filePath) {
-			os.Remove(filePath)
-		}
+	switch {
+	case bytes.HasSuffix(ending, []byte("\n\n")):
+		return nil, nil
+	case bytes.HasSuffix(ending, []byte("\n")):
+		return []byte("\n"), nil
 	}
+	return []byte("\n\n"), nil
 }
@@ -1,63 +1,201 @@
 package helpers
 
-import "testing"
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
 
-func TestProgramRootPathGetter(t *testing.T) {
-	var path, err = getProgramRootPath()
+func TestFileTypeGetter(t *testing.T) {
+	var fileType = getFileType(filepath.Join("summaries", "summary.pdf"))
+	if fileType != "pdf" {
+		t.Error("Expected 'pdf' file extension but received: ", fileType)
+	}
+}
+
+func TestStoringTextFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
 	if err != nil {
-		t.Error("Didn't expect error but received: ", err.Error())
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var summary = &Summary{
+		Title:     "Weekly news",
+		SourceURL: "http://test.test/news",
+		Metadata:  map[string]string{MetadataAuthor: "News desk"},
+		Text:      "First sentence.\nSecond sentence.",
+	}
+	var expectedContent = "Weekly news\n===========\n\nAuthor: News desk\n\n" +
+		"First sentence.\nSecond sentence.\n\nSource: http://test.test/news\n"
+
+	var path = filepath.Join(directory, "summary.txt")
+	stored, err := StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteOverwrite)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != expectedContent {
+		t.Error("Expected title, metadata, summary and source but received: ", string(content))
+	}
+
+	stored, err = StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteAppend)
+	if err != nil || !stored {
+		t.Fatal("Expected appended file but received: ", err)
 	}
 
-	if path == "" {
-		t.Error("Expected root path but received empty path")
+	content, _ = ioutil.ReadFile(path)
+	if string(content) != expectedContent+"\n"+expectedContent {
+		t.Error("Expected the summary twice but received: ", string(content))
+	}
+
+	stored, err = StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteFailIfExists)
+	if err == nil || stored {
+		t.Error("Expected error for existing file but received: ", stored)
+	}
+
+	_, err = StoreTextToFileWithMode(context.Background(), filepath.Join(directory, "summary.json"), summary, nil, WriteAppend)
+	if err == nil {
+		t.Error("Expected error for appending to json file but received none")
+	}
+
+	var files, _ = ioutil.ReadDir(directory)
+	if len(files) != 1 {
+		t.Error("Expected no temporary files to be left but received: ", len(files))
+	}
+}
+
+func TestFileTypeGetterWithMultipleExtensions(t *testing.T) {
+	var fileType = getFileType("reports/a.txt.PDF")
+	if fileType != "pdf" {
+		t.Error("Expected 'pdf' file extension but received: ", fileType)
 	}
 }
 
-func TestRandomFileNameGenerating(t *testing.T) {
-	var rootPath, err = getProgramRootPath()
+func TestStoringTextFileWithTitleAndText(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
 	if err != nil {
-		t.Error("Didn't expect error but received: ", err.Error())
+		t.Fatal("Didn't expect error but received: ", err.Error())
 	}
+	defer os.RemoveAll(directory)
 
-	var filename = generateRandomFileName(rootPath, "test")
-	if filename == "" {
-		t.Error("Expected filename but received empty string")
+	var path = filepath.Join(directory, "summary.txt")
+	stored, err := StoreTextToFile(path, "News", "First sentence.", nil)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != "News\n====\n\nFirst sentence.\n" {
+		t.Error("Expected title and text but received: ", string(content))
 	}
 }
 
-func TestRandomFileNameGeneratingExistence(t *testing.T) {
-	var rootPath, err = getProgramRootPath()
+func TestWritingFileAtomicallyDoesNotReplaceExistingFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
 	if err != nil {
-		t.Error("Didn't expect error but received: ", err.Error())
+		t.Fatal("Didn't expect error but received: ", err.Error())
 	}
+	defer os.RemoveAll(directory)
 
-	var filename = generateRandomFileName(rootPath, "test")
-	if fileExists(filename) {
-		t.Error("Expected generated file not to exist, but it does")
+	var path = filepath.Join(directory, "summary.txt")
+	if err = writeFileAtomically(path, []byte("first"), WriteFailIfExists); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	// A file created after the checks of StoreTextToFileWithMode, like by another process, must not be replaced
+	if err = writeFileAtomically(path, []byte("second"), WriteFailIfExists); err == nil {
+		t.Error("Expected error for existing file but received none")
+	}
+
+	var content, _ = ioutil.ReadFile(path)
+	if string(content) != "first" {
+		t.Error("Expected the first content to be kept but received: ", string(content))
+	}
+
+	var files, _ = ioutil.ReadDir(directory)
+	if len(files) != 1 {
+		t.Error("Expected no temporary files to be left but received: ", len(files))
 	}
 }
 
-func TestFileTypeGetter(t *testing.T) {
-	var rootPath, err = getProgramRootPath()
+func TestWritingFileAtomicallyKeepsPermissions(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
 	if err != nil {
-		t.Error("Didn't expect error but received: ", err.Error())
+		t.Fatal("Didn't expect error but received: ", err.Error())
 	}
+	defer os.RemoveAll(directory)
 
-	var filename = generateRandomFileName(rootPath, "pdf")
-	var fileType = getFileType(filename)
-	if fileType != "pdf" {
-		t.Error("Expected 'pdf' file extension but received: ", fileType)
+	var path = filepath.Join(directory, "summary.txt")
+	if err = ioutil.WriteFile(path, []byte("first"), 0600); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	os.Chmod(path, 0600)
+
+	if err = writeFileAtomically(path, []byte("second"), WriteOverwrite); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Error("Expected the permissions of the replaced file but received: ", info.Mode().Perm())
 	}
 }
 
-func TestFileExtensionFromURLGetter(t *testing.T) {
-	var testURL = "testurl/testimage.png"
-	var fileExtension, found = getFileExtensionFromURL(testURL)
-	if !found {
-		t.Error("Expected to find the file type but it didn't")
+func TestCreatingNewFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	// createNewFile is used when the filesystem doesn't support hard links
+	var path = filepath.Join(directory, "summary.txt")
+	if err = createNewFile(path, []byte("first"), 0644); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if err = createNewFile(path, []byte("second"), 0644); err == nil {
+		t.Error("Expected error for existing file but received none")
+	}
+
+	var content, _ = ioutil.ReadFile(path)
+	if string(content) != "first" {
+		t.Error("Expected the first content to be kept but received: ", string(content))
+	}
+}
+
+func TestAppendingToFileConcurrently(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "text-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var path = filepath.Join(directory, "summary.txt")
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		waitGroup.Add(1)
+		go func(number int) {
+			defer waitGroup.Done()
+			writeFileAtomically(path, []byte("Summary "+strconv.Itoa(number)+"\n"), WriteAppend)
+		}(i)
 	}
+	waitGroup.Wait()
 
-	if fileExtension != "png" {
-		t.Error("Expected 'png' file type but received: ", fileExtension)
+	var content, _ = ioutil.ReadFile(path)
+	for i := 0; i < 20; i++ {
+		if !strings.Contains(string(content), "Summary "+strconv.Itoa(i)+"\n") {
+			t.Error("Expected every appended summary but missing: ", i)
+		}
 	}
 }
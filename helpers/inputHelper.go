@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported input formats
+const (
+	FormatText     = "text"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+	FormatDOCX     = "docx"
+	FormatODT      = "odt"
+	FormatEPUB     = "epub"
+	FormatEmail    = "eml"
+	FormatMbox     = "mbox"
+	FormatSRT      = "srt"
+	FormatVTT      = "vtt"
+	FormatMarkdown = "md"
+)
+
+var formatsByExtension = map[string]string{
+	".txt":      FormatText,
+	".text":     FormatText,
+	".html":     FormatHTML,
+	".htm":      FormatHTML,
+	".xhtml":    FormatHTML,
+	".pdf":      FormatPDF,
+	".docx":     FormatDOCX,
+	".odt":      FormatODT,
+	".epub":     FormatEPUB,
+	".eml":      FormatEmail,
+	".mbox":     FormatMbox,
+	".mbx":      FormatMbox,
+	".srt":      FormatSRT,
+	".vtt":      FormatVTT,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+}
+
+// Document is the content read from a local source. Blocks are filled only
+// for structured formats and their text is joined as paragraphs in Text.
+// Chapters are filled only for books and Text contains all of them.
+// Cues are filled only for subtitles, one for every sentence of Text
+type Document struct {
+	Title    string
+	Text     string
+	Images   []string
+	Blocks   []Block
+	Chapters []Chapter
+	Cues     []Cue
+	Metadata map[string]string
+}
+
+// UnsupportedFormatError is returned when the format of the input can't be read
+type UnsupportedFormatError struct {
+	Format string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Format == "" {
+		return "Unknown input format"
+	}
+
+	return "Unsupported input format: " + e.Format
+}
+
+// DetectFormat detects the input format from the file name extension and,
+// if the extension is missing or unknown, from the content itself
+func DetectFormat(fileName string, data []byte) string {
+	var extension = strings.ToLower(filepath.Ext(fileName))
+	if format, found := formatsByExtension[extension]; found {
+		return format
+	}
+
+	return sniffFormat(data)
+}
+
+func sniffFormat(data []byte) string {
+	if findPDFHeader(data) >= 0 {
+		return FormatPDF
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return sniffZipFormat(data)
+	}
+
+	if format := sniffEmailFormat(data); format != "" {
+		return format
+	}
+
+	var trimmedData = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(trimmedData, []byte("WEBVTT")) {
+		return FormatVTT
+	}
+
+	// SRT files start with the number and the timing of the first cue
+	var firstLines = strings.SplitN(normalizeText(string(trimmedData)), "\n", 3)
+	if len(firstLines) >= 2 && cueTiming.MatchString(firstLines[1]) {
+		return FormatSRT
+	}
+
+	var contentType = http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "text/html") {
+		return FormatHTML
+	}
+
+	// XHTML documents start with an xml declaration instead of a html tag
+	if strings.HasPrefix(contentType, "text/xml") && bytes.Contains(bytes.ToLower(data), []byte("<html")) {
+		return FormatHTML
+	}
+
+	if strings.HasPrefix(contentType, "text/plain") || utf8.Valid(data) {
+		return FormatText
+	}
+
+	return ""
+}
+
+// ReadDocument reads the data in the given format. The baseURL is used
+// for resolving relative image urls in html documents and can be empty
+func ReadDocument(data []byte, format string, baseURL string) (*Document, error) {
+	switch format {
+	case FormatText:
+		return &Document{Text: normalizeText(string(data))}, nil
+	case FormatHTML:
+		var title, text, images, err = ExtractMainInfoFromHTML(string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var metadata = ExtractMetadataFromHTML(string(data))
+		return &Document{Title: title, Text: text, Images: resolveURLs(baseURL, images), Metadata: metadata}, nil
+	case FormatPDF:
+		var title, text, err = ExtractTextFromPDF(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Document{Title: title, Text: text}, nil
+	case FormatDOCX:
+		return readDOCXDocument(data)
+	case FormatODT:
+		return readODTDocument(data)
+	case FormatEPUB:
+		return readEPUBDocument(data)
+	case FormatEmail:
+		return readEmailDocument(data)
+	case FormatMbox:
+		return readMboxDocument(data)
+	case FormatSRT, FormatVTT:
+		return readSubtitleDocument(data, format)
+	case FormatMarkdown:
+		var document, err = readMarkdownDocument(data)
+		if err != nil {
+			return nil, err
+		}
+
+		document.Images = resolveURLs(baseURL, document.Images)
+		return document, nil
+	}
+
+	return nil, &UnsupportedFormatError{Format: format}
+}
+
+// normalizeText removes the byte order mark and unifies the line endings,
+// so paragraphs are always separated by "\n\n"
+func normalizeText(text string) string {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	text = strings.Replace(text, "\r", "\n", -1)
+	return text
+}
+
+// resolveURLs makes the urls absolute using the base url. The urls are unchanged if base is empty or invalid
+func resolveURLs(base string, urls []string) []string {
+	var baseURL, err = url.Parse(base)
+	if base == "" || err != nil {
+		return urls
+	}
+
+	var resolvedURLs = []string{}
+	for _, rawURL := range urls {
+		var parsedURL, err = url.Parse(strings.TrimSpace(rawURL))
+		if err != nil {
+			resolvedURLs = append(resolvedURLs, rawURL)
+			continue
+		}
+
+		resolvedURLs = append(resolvedURLs, baseURL.ResolveReference(parsedURL).String())
+	}
+
+	return resolvedURLs
+}
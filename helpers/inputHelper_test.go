@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatDetectionByExtension(t *testing.T) {
+	if format := DetectFormat("archive/page.HTM", []byte("plain text")); format != FormatHTML {
+		t.Error("Expected html format but received: ", format)
+	}
+
+	if format := DetectFormat("notes.txt", []byte("<html></html>")); format != FormatText {
+		t.Error("Expected text format but received: ", format)
+	}
+}
+
+func TestFormatDetectionBySniffing(t *testing.T) {
+	if format := DetectFormat("", []byte("  <!DOCTYPE html><html><body></body></html>")); format != FormatHTML {
+		t.Error("Expected html format but received: ", format)
+	}
+
+	if format := DetectFormat("page", []byte("first sentence. second sentence")); format != FormatText {
+		t.Error("Expected text format but received: ", format)
+	}
+
+	if format := DetectFormat("", []byte{0x00, 0xff, 0xfe, 0x01}); format != "" {
+		t.Error("Expected unknown format but received: ", format)
+	}
+
+	if format := DetectFormat("", []byte("Old files start with %PDF-1.4 and a binary comment.")); format != FormatText {
+		t.Error("Expected text mentioning the pdf header to be text but received: ", format)
+	}
+}
+
+func TestReadingPDFDocumentWithJunkBeforeHeader(t *testing.T) {
+	var data = append([]byte("\x00\x01junk\r\n"), buildTestPDF("Junk", "BT /F1 12 Tf 72 760 Td (Text after junk.) Tj ET", false)...)
+	if format := DetectFormat("", data); format != FormatPDF {
+		t.Fatal("Expected pdf format but received: ", format)
+	}
+
+	var document, err = ReadDocument(data, FormatPDF, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if document.Text != "Text after junk." {
+		t.Error("Expected 'Text after junk.' but received: ", document.Text)
+	}
+}
+
+func TestReadingHTMLDocument(t *testing.T) {
+	var testHTML = `<html><body><h1>Test title of the document</h1><div class="article"><p>First paragraph text.</p><img src="images/test.png"><p>Second paragraph text.</p></div></body></html>`
+	var document, err = ReadDocument([]byte(testHTML), FormatHTML, "http://test.test/articles/")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Test title of the document" {
+		t.Error("Expected document title but received: ", document.Title)
+	}
+
+	if len(document.Images) != 1 || document.Images[0] != "http://test.test/articles/images/test.png" {
+		t.Error("Expected resolved image url but received: ", document.Images)
+	}
+}
+
+func TestReadingTextDocumentNormalizesLineEndings(t *testing.T) {
+	var document, err = ReadDocument([]byte("\uFEFFfirst paragraph\r\n\r\nsecond paragraph"), FormatText, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Text != "first paragraph\n\nsecond paragraph" {
+		t.Error("Expected normalized text but received: ", document.Text)
+	}
+}
+
+func TestReadingUnsupportedDocument(t *testing.T) {
+	var _, err = ReadDocument([]byte("test"), "unknown", "")
+	var formatError *UnsupportedFormatError
+	if !errors.As(err, &formatError) {
+		t.Error("Expected UnsupportedFormatError but received: ", err)
+	}
+}
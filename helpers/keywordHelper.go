@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// keywordStopWords are the common English and Bulgarian words, which are never keywords
+var keywordStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true, "all": true,
+	"any": true, "can": true, "had": true, "her": true, "was": true, "one": true, "our": true, "out": true,
+	"has": true, "have": true, "his": true, "how": true, "its": true, "may": true, "new": true, "now": true,
+	"who": true, "did": true, "get": true, "him": true, "she": true, "too": true, "use": true, "that": true,
+	"with": true, "this": true, "from": true, "they": true, "will": true, "would": true, "there": true,
+	"their": true, "what": true, "about": true, "which": true, "when": true, "were": true, "been": true,
+	"into": true, "than": true, "then": true, "them": true, "these": true, "those": true, "some": true,
+	"could": true, "should": true, "other": true, "more": true, "most": true, "also": true, "only": true,
+	"over": true, "such": true, "very": true, "just": true, "where": true, "while": true, "after": true,
+	"before": true, "because": true, "being": true, "does": true, "each": true, "here": true, "like": true,
+	"said": true, "many": true, "much": true, "your": true, "why": true,
+	"и": true, "в": true, "на": true, "за": true, "се": true, "от": true, "да": true, "не": true,
+	"че": true, "са": true, "по": true, "с": true, "то": true, "как": true, "но": true, "или": true,
+	"това": true, "тази": true, "този": true, "които": true, "която": true, "който": true,
+	"като": true, "при": true, "след": true, "през": true, "има": true, "още": true, "вече": true,
+	"бъде": true, "беше": true, "много": true, "така": true, "също": true, "само": true, "може": true,
+	"тези": true, "него": true, "нея": true, "тях": true, "към": true, "над": true, "под": true,
+}
+
+// GetKeywords returns the most frequent words of the content, without the stop words and the short words.
+// Words with the same frequency are ordered by their first appearance
+func GetKeywords(content string, count int) []string {
+	var frequencies = make(map[string]int)
+	var words = []string{}
+
+	var fields = strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+	for _, word := range fields {
+		word = strings.Trim(word, "-'")
+		if len([]rune(word)) < 3 || keywordStopWords[word] || isNumber(word) {
+			continue
+		}
+
+		if frequencies[word] == 0 {
+			words = append(words, word)
+		}
+		frequencies[word]++
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return frequencies[words[i]] > frequencies[words[j]]
+	})
+
+	if len(words) > count {
+		words = words[:count]
+	}
+	return words
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,290 @@
+package helpers
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var markdownHeading, _ = regexp.Compile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+var markdownSetextUnderline, _ = regexp.Compile(`^ {0,3}(=+|-+)\s*$`)
+var markdownListItem, _ = regexp.Compile(`^(\s*)(?:[-*+]|\d{1,9}[.)])\s+(.*)$`)
+var markdownFence, _ = regexp.Compile("^ {0,3}(```+|~~~+)")
+var markdownRule, _ = regexp.Compile(`^ {0,3}([-*_])(\s*([-*_]))*\s*$`)
+var markdownImage, _ = regexp.Compile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+var markdownLink, _ = regexp.Compile(`\[([^\]]*)\]\([^)]*\)|\[([^\]]+)\]\[[^\]]*\]`)
+var markdownAutolink, _ = regexp.Compile(`<((?:https?|mailto):[^>\s]+)>`)
+var markdownInlineCode, _ = regexp.Compile("`+([^`]*)`+")
+var markdownEmphasis, _ = regexp.Compile(`(\*{1,3}|~~)([^\s*~](?:.*?[^\s*~])?)(\*{1,3}|~~)`)
+var markdownUnderscoreEmphasis, _ = regexp.Compile(`(^|[^\pL\pN_])_{1,3}([^\s_](?:.*?[^\s_])?)_{1,3}($|[^\pL\pN_])`)
+var markdownHTMLTag, _ = regexp.Compile(`<!--.*?-->|</?[a-zA-Z][^>]*>`)
+var markdownOrderedItem, _ = regexp.Compile(`^\d{1,9}[.)]`)
+var markdownSpecialChars, _ = regexp.Compile("([\\\\`*_\\[\\]<>])")
+
+// readMarkdownDocument reads the headings, list items, paragraphs and code of a Markdown document.
+// The code blocks are kept as blocks, but they are not part of the text, so they are never ranked
+func readMarkdownDocument(data []byte) (*Document, error) {
+	var text = normalizeText(string(data))
+	var document = &Document{Metadata: make(map[string]string)}
+
+	text = readFrontMatter(text, document.Metadata)
+	document.Title = document.Metadata["title"]
+	delete(document.Metadata, "title")
+
+	var blocks = []Block{}
+	var paragraph = []string{}
+	var fence = ""
+	var code = []string{}
+
+	var endParagraph = func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Block{Kind: BlockParagraph, Text: strings.Join(paragraph, " ")})
+			paragraph = []string{}
+		}
+	}
+
+	var lines = strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		var line = lines[i]
+
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				blocks = append(blocks, Block{Kind: BlockCode, Text: strings.Join(code, "\n")})
+				fence = ""
+				code = []string{}
+			} else {
+				code = append(code, line)
+			}
+			continue
+		}
+
+		if match := markdownFence.FindStringSubmatch(line); match != nil {
+			endParagraph()
+			fence = match[1]
+			continue
+		}
+
+		var trimmedLine = strings.TrimSpace(line)
+		if trimmedLine == "" {
+			endParagraph()
+			continue
+		}
+
+		// Indented code can't interrupt a paragraph or continue a list item
+		if len(paragraph) == 0 && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && !isMarkdownListContinuation(blocks) {
+			var codeLines = []string{}
+			for ; i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.HasPrefix(lines[i], "\t") || strings.TrimSpace(lines[i]) == ""); i++ {
+				codeLines = append(codeLines, strings.TrimPrefix(strings.TrimPrefix(lines[i], "\t"), "    "))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: BlockCode, Text: strings.TrimRight(strings.Join(codeLines, "\n"), "\n")})
+			continue
+		}
+
+		if len(paragraph) > 0 && markdownSetextUnderline.MatchString(line) {
+			var level = 1
+			if strings.Contains(line, "-") {
+				level = 2
+			}
+			blocks = append(blocks, Block{Kind: BlockHeading, Text: cleanMarkdownInline(strings.Join(paragraph, " "), document), Level: level})
+			paragraph = []string{}
+			continue
+		}
+
+		if markdownRule.MatchString(line) {
+			endParagraph()
+			continue
+		}
+
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			endParagraph()
+			blocks = append(blocks, Block{Kind: BlockHeading, Text: cleanMarkdownInline(match[2], document), Level: len(match[1])})
+			continue
+		}
+
+		if match := markdownListItem.FindStringSubmatch(line); match != nil {
+			endParagraph()
+			var indentation = len(strings.Replace(match[1], "\t", "    ", -1))
+			blocks = append(blocks, Block{Kind: BlockListItem, Text: cleanMarkdownInline(match[2], document), Level: indentation/2 + 1})
+			continue
+		}
+
+		// Tables are data rather than sentences
+		if strings.HasPrefix(trimmedLine, "|") {
+			endParagraph()
+			continue
+		}
+
+		// Quotes are read as normal paragraphs
+		trimmedLine = strings.TrimSpace(strings.TrimLeft(trimmedLine, "> "))
+
+		// Lines of a list item continue it
+		if len(paragraph) == 0 && isMarkdownListContinuation(blocks) && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			blocks[len(blocks)-1].Text += " " + cleanMarkdownInline(trimmedLine, document)
+			continue
+		}
+
+		paragraph = append(paragraph, cleanMarkdownInline(trimmedLine, document))
+	}
+
+	endParagraph()
+	if fence != "" {
+		blocks = append(blocks, Block{Kind: BlockCode, Text: strings.Join(code, "\n")})
+	}
+
+	// The first top level heading is the title, if the front matter doesn't have one
+	for i, block := range blocks {
+		if document.Title == "" && block.Kind == BlockHeading && block.Level == 1 {
+			document.Title = block.Text
+			blocks = append(blocks[:i], blocks[i+1:]...)
+			break
+		}
+	}
+
+	document.Blocks = blocks
+	document.Text = blocksToText(blocks)
+	return document, nil
+}
+
+func isMarkdownListContinuation(blocks []Block) bool {
+	return len(blocks) > 0 && blocks[len(blocks)-1].Kind == BlockListItem
+}
+
+// readFrontMatter reads the "key: value" lines of the YAML front matter into the metadata
+// and returns the text after it. Nested values and lists are skipped
+func readFrontMatter(text string, metadata map[string]string) string {
+	if !strings.HasPrefix(text, "---\n") {
+		return text
+	}
+
+	var end = strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return text
+	}
+
+	for _, line := range strings.Split(text[4:4+end], "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		var separator = strings.Index(line, ":")
+		if separator <= 0 {
+			continue
+		}
+
+		var key = strings.ToLower(strings.TrimSpace(line[:separator]))
+		var value = strings.Trim(strings.TrimSpace(line[separator+1:]), `"'`)
+		if key != "" && value != "" {
+			metadata[key] = value
+		}
+	}
+
+	var rest = text[4+end+4:]
+	if newLine := strings.Index(rest, "\n"); newLine >= 0 {
+		return rest[newLine+1:]
+	}
+	return ""
+}
+
+// cleanMarkdownInline removes the inline formatting, keeping the text of the links
+// and adding the images to the document
+func cleanMarkdownInline(text string, document *Document) string {
+	for _, match := range markdownImage.FindAllStringSubmatch(text, -1) {
+		document.Images = append(document.Images, match[2])
+	}
+
+	text = markdownImage.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1$2")
+	text = markdownAutolink.ReplaceAllString(text, "$1")
+	text = markdownInlineCode.ReplaceAllString(text, "$1")
+	text = markdownHTMLTag.ReplaceAllString(text, "")
+	// Underscores inside of words, like in snake_case names, are not emphasis
+	for markdownEmphasis.MatchString(text) || markdownUnderscoreEmphasis.MatchString(text) {
+		text = markdownEmphasis.ReplaceAllString(text, "$2")
+		text = markdownUnderscoreEmphasis.ReplaceAllString(text, "$1$2$3")
+	}
+
+	return collapseSpaces(strings.Replace(text, "\\", "", -1))
+}
+
+// escapeMarkdown escapes the characters, which would be read as formatting
+func escapeMarkdown(text string) string {
+	text = markdownSpecialChars.ReplaceAllString(text, "\\$1")
+	if strings.HasPrefix(text, "#") || strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		return "\\" + text
+	}
+	if match := markdownOrderedItem.FindStringIndex(text); match != nil {
+		// The number must stay, so the dot after it is escaped
+		return text[:match[1]-1] + "\\" + text[match[1]-1:]
+	}
+	return text
+}
+
+// isMarkdownLinkURL tells if the address is a web url, which can be written between angle brackets as a link
+func isMarkdownLinkURL(address string) bool {
+	return isWebURL(address) && !strings.ContainsAny(address, "<> \t\r\n")
+}
+
+// formatMarkdownLink writes web urls as autolinks and other addresses, like local paths, as escaped text
+func formatMarkdownLink(address string) string {
+	if isMarkdownLinkURL(address) {
+		return "<" + address + ">"
+	}
+	return escapeMarkdown(address)
+}
+
+// renderMarkdown writes the title, metadata, summary sentences as bullets and the images
+func renderMarkdown(w io.Writer, summary *Summary) error {
+	var content bytes.Buffer
+	if summary.Title != "" {
+		content.WriteString("# " + escapeMarkdown(summary.Title) + "\n\n")
+	}
+
+	var metadataLines = []string{}
+	if summary.SourceURL != "" {
+		metadataLines = append(metadataLines, "- **Source:** "+formatMarkdownLink(summary.SourceURL))
+	}
+	var keys = make([]string, 0, len(summary.Metadata))
+	for key := range summary.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		metadataLines = append(metadataLines, "- **"+escapeMarkdown(metadataLabel(key))+":** "+escapeMarkdown(summary.Metadata[key]))
+	}
+	if len(metadataLines) > 0 {
+		content.WriteString(strings.Join(metadataLines, "\n") + "\n\n")
+	}
+
+	content.WriteString("## Summary\n\n")
+	for _, sentence := range summary.SentenceTexts() {
+		content.WriteString("- " + escapeMarkdown(sentence) + "\n")
+	}
+
+	for _, chapter := range summary.Chapters {
+		if chapter.Summary == "" {
+			continue
+		}
+
+		content.WriteString("\n### " + escapeMarkdown(chapter.Title) + "\n\n")
+		for _, sentence := range strings.Split(chapter.Summary, "\n") {
+			content.WriteString("- " + escapeMarkdown(sentence) + "\n")
+		}
+	}
+
+	if len(summary.Images) > 0 {
+		content.WriteString("\n## Images\n\n")
+		for _, image := range summary.Images {
+			if isMarkdownLinkURL(image) {
+				content.WriteString("![](<" + image + ">)\n")
+			} else {
+				content.WriteString("- " + escapeMarkdown(image) + "\n")
+			}
+		}
+	}
+
+	var _, err = w.Write(content.Bytes())
+	return err
+}
@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testMarkdown = "---\ntitle: Release notes\nauthor: Docs team\ntags:\n  - go\n---\n" +
+	"# Overview\n\nThe **new** release adds [summaries](http://test.test/docs)\nfor `get_summary_info` users.\n\n" +
+	"```go\nfmt.Println(\"code is not ranked\")\n```\n\n" +
+	"Install it\n----------\n\n- First step\n  continues here\n    - Nested step\n\n![diagram](images/diagram.png)\n"
+
+func TestReadingMarkdownDocument(t *testing.T) {
+	var document, err = ReadDocument([]byte(testMarkdown), FormatMarkdown, "http://test.test/docs/")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Release notes" || document.Metadata[MetadataAuthor] != "Docs team" {
+		t.Error("Expected the title and metadata from the front matter but received: ", document.Title, document.Metadata)
+	}
+
+	var expectedBlocks = []Block{
+		{Kind: BlockHeading, Text: "Overview", Level: 1},
+		{Kind: BlockParagraph, Text: "The new release adds summaries for get_summary_info users."},
+		{Kind: BlockCode, Text: "fmt.Println(\"code is not ranked\")"},
+		{Kind: BlockHeading, Text: "Install it", Level: 2},
+		{Kind: BlockListItem, Text: "First step continues here", Level: 1},
+		{Kind: BlockListItem, Text: "Nested step", Level: 3},
+		{Kind: BlockParagraph, Text: "diagram"},
+	}
+	if len(document.Blocks) != len(expectedBlocks) {
+		t.Fatal("Expected 7 blocks but received: ", document.Blocks)
+	}
+	for i, block := range expectedBlocks {
+		if document.Blocks[i] != block {
+			t.Error("Expected block ", block, " but received: ", document.Blocks[i])
+		}
+	}
+
+	var expectedText = "Overview\n\nThe new release adds summaries for get_summary_info users.\n\nInstall it\n\nFirst step continues here\n\nNested step\n\ndiagram"
+	if document.Text != expectedText {
+		t.Error("Expected the text without code but received: ", document.Text)
+	}
+
+	if len(document.Images) != 1 || document.Images[0] != "http://test.test/docs/images/diagram.png" {
+		t.Error("Expected the resolved image but received: ", document.Images)
+	}
+}
+
+func TestStoringMarkdownFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "markdown-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var summary = &Summary{
+		Title:     "Release *notes*",
+		SourceURL: "http://test.test/notes",
+		Metadata:  map[string]string{MetadataAuthor: "Docs team"},
+		Text:      "First sentence\n1. Second sentence",
+		Images:    []string{"http://test.test/image.png"},
+	}
+
+	var path = filepath.Join(directory, "summary.md")
+	stored, err := StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteOverwrite)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedContent = "# Release \\*notes\\*\n\n- **Source:** <http://test.test/notes>\n- **Author:** Docs team\n\n" +
+		"## Summary\n\n- First sentence\n- 1\\. Second sentence\n\n## Images\n\n![](<http://test.test/image.png>)\n"
+	if string(content) != expectedContent {
+		t.Error("Expected markdown summary but received: ", string(content))
+	}
+}
+
+func TestRenderingMarkdownWithLocalSources(t *testing.T) {
+	var summary = &Summary{
+		Title:     "Notes",
+		SourceURL: "notes/<draft>.md",
+		Metadata:  map[string]string{"*key*": "value"},
+		Text:      "First sentence.",
+		Images:    []string{"javascript:alert(1)", "images/a>b.png", "https://test.test/a.png"},
+	}
+
+	var content bytes.Buffer
+	if err := RenderSummary(context.Background(), &content, "md", summary, nil); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedContent = "# Notes\n\n- **Source:** notes/\\<draft\\>.md\n- **\\*key\\*:** value\n\n## Summary\n\n- First sentence.\n\n" +
+		"## Images\n\n- javascript:alert(1)\n- images/a\\>b.png\n![](<https://test.test/a.png>)\n"
+	if content.String() != expectedContent {
+		t.Error("Expected local sources as text but received: ", content.String())
+	}
+}
+
+func TestRenderingEmptyAndCyrillicMetadataKeys(t *testing.T) {
+	var document, err = ReadDocument([]byte("---\n\t: v\nавтор: Иван\n---\nFirst sentence. Second sentence.\n"), FormatMarkdown, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if _, found := document.Metadata[""]; found || document.Metadata["автор"] != "Иван" {
+		t.Error("Expected only the 'автор' key in the front matter metadata but received: ", document.Metadata)
+	}
+
+	var summary = &Summary{Title: "News", Text: "First sentence.", Metadata: map[string]string{"": "v", "автор": "Иван"}}
+	for _, format := range []string{"txt", "md", "html", "docx", "epub"} {
+		var content bytes.Buffer
+		if err := RenderSummary(context.Background(), &content, format, summary, nil); err != nil {
+			t.Fatal("Didn't expect error for "+format+" but received: ", err.Error())
+		}
+
+		var text = content.String()
+		if format == "docx" {
+			var document, err = ReadDocument(content.Bytes(), format, "")
+			if err != nil {
+				t.Fatal("Didn't expect error for "+format+" but received: ", err.Error())
+			}
+			text = document.Text
+		} else if format == "epub" {
+			var archive, err = zip.NewReader(bytes.NewReader(content.Bytes()), int64(content.Len()))
+			if err != nil {
+				t.Fatal("Didn't expect error for "+format+" but received: ", err.Error())
+			}
+			chapter, err := readZipFile(archive, "OEBPS/chapter-1.xhtml")
+			if err != nil {
+				t.Fatal("Didn't expect error for "+format+" but received: ", err.Error())
+			}
+			text = string(chapter)
+		}
+		if !strings.Contains(text, "Автор") {
+			t.Error("Expected 'Автор' label in "+format+" but received: ", text)
+		}
+	}
+}
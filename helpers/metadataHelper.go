@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Metadata keys filled from the sources
+const (
+	MetadataAuthor      = "author"
+	MetadataPublished   = "published"
+	MetadataDescription = "description"
+	MetadataSite        = "site"
+	MetadataKeywords    = "keywords"
+	MetadataLanguage    = "language"
+)
+
+// metadataMetaNames maps the names and properties of the html meta tags to the metadata keys.
+// The first found tag for every key wins, so the more specific names are first
+var metadataMetaNames = []struct {
+	name string
+	key  string
+}{
+	{"author", MetadataAuthor},
+	{"article:author", MetadataAuthor},
+	{"dc.creator", MetadataAuthor},
+	{"article:published_time", MetadataPublished},
+	{"date", MetadataPublished},
+	{"dc.date", MetadataPublished},
+	{"description", MetadataDescription},
+	{"og:description", MetadataDescription},
+	{"og:site_name", MetadataSite},
+	{"application-name", MetadataSite},
+	{"keywords", MetadataKeywords},
+	{"news_keywords", MetadataKeywords},
+}
+
+// ExtractMetadataFromHTML reads the author, publishing date, description, site name,
+// keywords and language of the page from its meta tags
+func ExtractMetadataFromHTML(htmlString string) map[string]string {
+	var metadata = make(map[string]string)
+	var doc, err = html.Parse(strings.NewReader(htmlString))
+	if err != nil {
+		return metadata
+	}
+
+	var values = make(map[string]string)
+	for _, meta := range extractNodes(doc, "meta") {
+		var name, hasName = getAttribute(meta, "name")
+		if !hasName {
+			name, _ = getAttribute(meta, "property")
+		}
+
+		var content, _ = getAttribute(meta, "content")
+		name = strings.ToLower(strings.TrimSpace(name))
+		content = collapseSpaces(content)
+		if name != "" && content != "" && values[name] == "" {
+			values[name] = content
+		}
+	}
+
+	for _, metaName := range metadataMetaNames {
+		if value := values[metaName.name]; value != "" && metadata[metaName.key] == "" {
+			metadata[metaName.key] = value
+		}
+	}
+
+	for _, htmlNode := range extractNodes(doc, "html") {
+		if language, found := getAttribute(htmlNode, "lang"); found && strings.TrimSpace(language) != "" {
+			metadata[MetadataLanguage] = strings.TrimSpace(language)
+		}
+	}
+
+	return metadata
+}
+
+// metadataLabel returns the metadata key with capital first letter, like "Author", for the rendered summaries
+func metadataLabel(key string) string {
+	var first, size = utf8.DecodeRuneInString(key)
+	if size == 0 {
+		return ""
+	}
+
+	return string(unicode.ToUpper(first)) + key[size:]
+}
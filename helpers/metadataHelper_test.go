@@ -0,0 +1,25 @@
+package helpers
+
+import "testing"
+
+func TestExtractingMetadataFromHTML(t *testing.T) {
+	var testHTML = `<html lang="en"><head><meta name="author" content="Jane Doe"><meta property="og:description" content="Open graph description">` +
+		`<meta name="description" content="Page  description"><meta property="article:published_time" content="2018-01-01"></head><body></body></html>`
+
+	var metadata = ExtractMetadataFromHTML(testHTML)
+	var expectedMetadata = map[string]string{
+		MetadataAuthor:      "Jane Doe",
+		MetadataDescription: "Page description",
+		MetadataPublished:   "2018-01-01",
+		MetadataLanguage:    "en",
+	}
+
+	if len(metadata) != len(expectedMetadata) {
+		t.Error("Expected 4 metadata values but received: ", metadata)
+	}
+	for key, value := range expectedMetadata {
+		if metadata[key] != value {
+			t.Error("Expected "+key+" to be "+value+" but received: ", metadata[key])
+		}
+	}
+}
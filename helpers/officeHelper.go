@@ -0,0 +1,424 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Kinds of the document blocks
+const (
+	BlockParagraph = "paragraph"
+	BlockHeading   = "heading"
+	BlockListItem  = "list-item"
+	BlockCode      = "code"
+)
+
+// Block is a paragraph, heading or list item of a document. Level is the heading level
+// or the nesting level of a list item, starting from 1, and 0 for paragraphs
+type Block struct {
+	Kind  string
+	Text  string
+	Level int
+}
+
+const (
+	wordNamespace       = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	odtTextNamespace    = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odtOfficeNamespace  = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+	odtMimeType         = "application/vnd.oasis.opendocument.text"
+)
+
+// blocksToText joins the blocks text, separating them as paragraphs. Code isn't prose, so it's left out
+func blocksToText(blocks []Block) string {
+	var texts = []string{}
+	for _, block := range blocks {
+		if block.Text != "" && block.Kind != BlockCode {
+			texts = append(texts, block.Text)
+		}
+	}
+
+	return strings.Join(texts, "\n\n")
+}
+
+// collapseSpaces replaces the tabs, new lines and repeated spaces with a single space
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// readZipFile reads a file from the archive, limited to MaxBodySize bytes
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+
+		var reader, err = file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return readFromReader(reader, MaxBodySize)
+	}
+
+	return nil, errors.New("Missing file in the archive: " + name)
+}
+
+// sniffZipFormat detects the format of office documents and other zip based formats
+func sniffZipFormat(data []byte) string {
+	var archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+
+	if mimeType, err := readZipFile(archive, "mimetype"); err == nil {
+		switch strings.TrimSpace(string(mimeType)) {
+		case odtMimeType:
+			return FormatODT
+		case epubMimeType:
+			return FormatEPUB
+		}
+	}
+
+	for _, file := range archive.File {
+		if file.Name == "word/document.xml" {
+			return FormatDOCX
+		}
+	}
+
+	return ""
+}
+
+// getXMLAttribute returns the value of the attribute with the given local name
+func getXMLAttribute(element xml.StartElement, name string) string {
+	for _, attribute := range element.Attr {
+		if attribute.Name.Local == name {
+			return attribute.Value
+		}
+	}
+
+	return ""
+}
+
+// readDublinCoreTitle reads the dc:title of the document metadata
+func readDublinCoreTitle(data []byte) string {
+	var decoder = xml.NewDecoder(bytes.NewReader(data))
+	for {
+		var token, err = decoder.Token()
+		if err != nil {
+			return ""
+		}
+
+		if element, isStart := token.(xml.StartElement); isStart && element.Name.Space == dublinCoreNamespace && element.Name.Local == "title" {
+			var title string
+			if decoder.DecodeElement(&title, &element) != nil {
+				return ""
+			}
+			return collapseSpaces(title)
+		}
+	}
+}
+
+// readDOCXDocument reads the paragraphs, headings and list items of a Word document
+func readDOCXDocument(data []byte) (*Document, error) {
+	var archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var document = new(Document)
+	if core, err := readZipFile(archive, "docProps/core.xml"); err == nil {
+		document.Title = readDublinCoreTitle(core)
+	}
+
+	var styles = map[string]string{}
+	if stylesContent, err := readZipFile(archive, "word/styles.xml"); err == nil {
+		styles = readDOCXStyleNames(stylesContent)
+	}
+
+	document.Blocks, err = readDOCXBlocks(content, styles, &document.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	document.Text = blocksToText(document.Blocks)
+	return document, nil
+}
+
+// readDOCXStyleNames maps the style ids to their lowercase names, because the ids
+// of the built-in styles are translated in localized versions of Word, but the names are not
+func readDOCXStyleNames(data []byte) map[string]string {
+	var names = make(map[string]string)
+	var decoder = xml.NewDecoder(bytes.NewReader(data))
+	var styleID = ""
+
+	for {
+		var token, err = decoder.Token()
+		if err != nil {
+			return names
+		}
+
+		if element, isStart := token.(xml.StartElement); isStart && element.Name.Space == wordNamespace {
+			switch element.Name.Local {
+			case "style":
+				styleID = getXMLAttribute(element, "styleId")
+			case "name":
+				if styleID != "" {
+					names[styleID] = strings.ToLower(getXMLAttribute(element, "val"))
+				}
+			}
+		}
+	}
+}
+
+type docxParagraph struct {
+	text      strings.Builder
+	style     string
+	isList    bool
+	listLevel int
+	outline   int
+}
+
+func readDOCXBlocks(content []byte, styles map[string]string, title *string) ([]Block, error) {
+	var blocks = []Block{}
+	var decoder = xml.NewDecoder(bytes.NewReader(content))
+	var paragraphs = []*docxParagraph{}
+	var inText = false
+
+	for {
+		var token, err = decoder.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Space != wordNamespace {
+				continue
+			}
+
+			if element.Name.Local == "p" {
+				paragraphs = append(paragraphs, &docxParagraph{outline: -1})
+				continue
+			}
+
+			if len(paragraphs) == 0 {
+				continue
+			}
+
+			var paragraph = paragraphs[len(paragraphs)-1]
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				paragraph.text.WriteString(" ")
+			case "pStyle":
+				paragraph.style = getXMLAttribute(element, "val")
+			case "numPr":
+				paragraph.isList = true
+			case "ilvl":
+				paragraph.listLevel, _ = strconv.Atoi(getXMLAttribute(element, "val"))
+			case "outlineLvl":
+				if level, err := strconv.Atoi(getXMLAttribute(element, "val")); err == nil {
+					paragraph.outline = level
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Space != wordNamespace {
+				continue
+			}
+
+			if element.Name.Local == "t" {
+				inText = false
+			} else if element.Name.Local == "p" && len(paragraphs) > 0 {
+				var paragraph = paragraphs[len(paragraphs)-1]
+				paragraphs = paragraphs[:len(paragraphs)-1]
+
+				var block, isTitle = paragraph.toBlock(styles)
+				if isTitle {
+					if *title == "" {
+						*title = block.Text
+					}
+				} else if block.Text != "" {
+					blocks = append(blocks, block)
+				}
+			}
+		case xml.CharData:
+			if inText && len(paragraphs) > 0 {
+				paragraphs[len(paragraphs)-1].text.Write(element)
+			}
+		}
+	}
+}
+
+// toBlock converts the paragraph to a block by its style. It also reports if the paragraph is the document title
+func (p *docxParagraph) toBlock(styles map[string]string) (Block, bool) {
+	var block = Block{Kind: BlockParagraph, Text: collapseSpaces(p.text.String())}
+
+	var styleName = styles[p.style]
+	if styleName == "" {
+		styleName = strings.ToLower(p.style)
+	}
+
+	if styleName == "title" {
+		return block, true
+	}
+
+	if strings.HasPrefix(styleName, "heading") {
+		var level, err = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(styleName, "heading")))
+		if err == nil && level > 0 {
+			block.Kind = BlockHeading
+			block.Level = level
+			return block, false
+		}
+	}
+
+	if p.outline >= 0 && p.outline < 9 {
+		block.Kind = BlockHeading
+		block.Level = p.outline + 1
+		return block, false
+	}
+
+	if p.isList || strings.HasPrefix(styleName, "list") {
+		block.Kind = BlockListItem
+		block.Level = p.listLevel + 1
+	}
+
+	return block, false
+}
+
+// readODTDocument reads the paragraphs, headings and list items of an OpenDocument text document
+func readODTDocument(data []byte) (*Document, error) {
+	var archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readZipFile(archive, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var document = new(Document)
+	if meta, err := readZipFile(archive, "meta.xml"); err == nil {
+		document.Title = readDublinCoreTitle(meta)
+	}
+
+	document.Blocks, err = readODTBlocks(content, &document.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	document.Text = blocksToText(document.Blocks)
+	return document, nil
+}
+
+type odtParagraph struct {
+	text  strings.Builder
+	block Block
+	style string
+}
+
+func readODTBlocks(content []byte, title *string) ([]Block, error) {
+	var blocks = []Block{}
+	var decoder = xml.NewDecoder(bytes.NewReader(content))
+	var paragraphs = []*odtParagraph{}
+	var listDepth = 0
+	var skippedDepth = 0
+
+	for {
+		var token, err = decoder.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			var isText = element.Name.Space == odtTextNamespace
+			var isAnnotation = element.Name.Space == odtOfficeNamespace && element.Name.Local == "annotation"
+
+			// Footnotes and comments are not part of the main text
+			if skippedDepth > 0 || isAnnotation || (isText && element.Name.Local == "note") {
+				skippedDepth++
+				continue
+			}
+
+			if !isText {
+				continue
+			}
+
+			switch element.Name.Local {
+			case "list":
+				listDepth++
+			case "p", "h":
+				var paragraph = &odtParagraph{block: Block{Kind: BlockParagraph}, style: getXMLAttribute(element, "style-name")}
+				if element.Name.Local == "h" {
+					paragraph.block.Kind = BlockHeading
+					paragraph.block.Level, _ = strconv.Atoi(getXMLAttribute(element, "outline-level"))
+					if paragraph.block.Level < 1 {
+						paragraph.block.Level = 1
+					}
+				} else if listDepth > 0 {
+					paragraph.block.Kind = BlockListItem
+					paragraph.block.Level = listDepth
+				}
+				paragraphs = append(paragraphs, paragraph)
+			case "s", "tab", "line-break":
+				if len(paragraphs) > 0 {
+					paragraphs[len(paragraphs)-1].text.WriteString(" ")
+				}
+			}
+		case xml.EndElement:
+			if skippedDepth > 0 {
+				skippedDepth--
+				continue
+			}
+
+			if element.Name.Space != odtTextNamespace {
+				continue
+			}
+
+			switch element.Name.Local {
+			case "list":
+				listDepth--
+			case "p", "h":
+				if len(paragraphs) == 0 {
+					continue
+				}
+
+				var paragraph = paragraphs[len(paragraphs)-1]
+				paragraphs = paragraphs[:len(paragraphs)-1]
+				paragraph.block.Text = collapseSpaces(paragraph.text.String())
+
+				if strings.EqualFold(paragraph.style, "Title") {
+					if *title == "" {
+						*title = paragraph.block.Text
+					}
+				} else if paragraph.block.Text != "" {
+					blocks = append(blocks, paragraph.block)
+				}
+			}
+		case xml.CharData:
+			if skippedDepth == 0 && len(paragraphs) > 0 {
+				paragraphs[len(paragraphs)-1].text.Write(element)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// createTestArchive zips the files in the given order
+func createTestArchive(files ...string) []byte {
+	var buffer bytes.Buffer
+	var writer = zip.NewWriter(&buffer)
+	for i := 0; i+1 < len(files); i += 2 {
+		var file, _ = writer.Create(files[i])
+		file.Write([]byte(files[i+1]))
+	}
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestReadingDOCXDocument(t *testing.T) {
+	var content = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Service agreement</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="berschrift1"/></w:pPr><w:r><w:t>Terms</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">The first </w:t></w:r><w:r><w:t>paragraph.</w:t></w:r><w:del><w:r><w:delText>Deleted text.</w:delText></w:r></w:del></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t>Nested list item</w:t></w:r></w:p>
+</w:body></w:document>`
+	var styles = `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:style w:styleId="berschrift1"><w:name w:val="heading 1"/></w:style></w:styles>`
+
+	var document, err = ReadDocument(createTestArchive("word/document.xml", content, "word/styles.xml", styles), FormatDOCX, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Service agreement" {
+		t.Error("Expected the title paragraph as title but received: ", document.Title)
+	}
+
+	var expectedBlocks = []Block{
+		{Kind: BlockHeading, Text: "Terms", Level: 1},
+		{Kind: BlockParagraph, Text: "The first paragraph."},
+		{Kind: BlockListItem, Text: "Nested list item", Level: 2},
+	}
+	if len(document.Blocks) != len(expectedBlocks) {
+		t.Fatal("Expected 3 blocks but received: ", document.Blocks)
+	}
+	for i, block := range expectedBlocks {
+		if document.Blocks[i] != block {
+			t.Error("Expected block ", block, " but received: ", document.Blocks[i])
+		}
+	}
+
+	if document.Text != "Terms\n\nThe first paragraph.\n\nNested list item" {
+		t.Error("Expected blocks separated as paragraphs but received: ", document.Text)
+	}
+}
+
+func TestReadingODTDocument(t *testing.T) {
+	var content = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text>
+<text:h text:outline-level="2">Scope</text:h>
+<text:p>First<text:s/>paragraph<text:note><text:note-body><text:p>Footnote.</text:p></text:note-body></text:note> text.</text:p>
+<text:list><text:list-item><text:p>Top item</text:p><text:list><text:list-item><text:p>Inner item</text:p></text:list-item></text:list></text:list-item></text:list>
+</office:text></office:body></office:document-content>`
+	var meta = `<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><office:meta><dc:title>Contract notes</dc:title></office:meta></office:document-meta>`
+
+	var data = createTestArchive("mimetype", "application/vnd.oasis.opendocument.text", "content.xml", content, "meta.xml", meta)
+	if format := DetectFormat("", data); format != FormatODT {
+		t.Error("Expected odt format but received: ", format)
+	}
+
+	var document, err = ReadDocument(data, FormatODT, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Contract notes" {
+		t.Error("Expected the metadata title but received: ", document.Title)
+	}
+
+	var expectedBlocks = []Block{
+		{Kind: BlockHeading, Text: "Scope", Level: 2},
+		{Kind: BlockParagraph, Text: "First paragraph text."},
+		{Kind: BlockListItem, Text: "Top item", Level: 1},
+		{Kind: BlockListItem, Text: "Inner item", Level: 2},
+	}
+	if len(document.Blocks) != len(expectedBlocks) {
+		t.Fatal("Expected 4 blocks but received: ", document.Blocks)
+	}
+	for i, block := range expectedBlocks {
+		if document.Blocks[i] != block {
+			t.Error("Expected block ", block, " but received: ", document.Blocks[i])
+		}
+	}
+}
+
+func TestDetectingDOCXFormat(t *testing.T) {
+	var data = createTestArchive("[Content_Types].xml", "<Types/>", "word/document.xml", "<w:document/>")
+	if format := DetectFormat("contract", data); format != FormatDOCX {
+		t.Error("Expected docx format but received: ", format)
+	}
+
+	if format := DetectFormat("", createTestArchive("readme.txt", "text")); format != "" {
+		t.Error("Expected unknown format for other archives but received: ", format)
+	}
+}
@@ -0,0 +1,250 @@
+package helpers
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultMaxPages is the default limit of pages downloaded for a single multi-page article
+const DefaultMaxPages = 5
+
+var paginationContainer, _ = regexp.Compile("(?i)(pagination|pager|paging|page-nav|pagenav|page-numbers|pages)")
+var nextLinkText, _ = regexp.Compile("(?i)^(next|next page|next ›|next »|continue reading|›|»|>|→|следваща|следваща страница)$")
+var nextLinkClass, _ = regexp.Compile("(?i)(^|[-_ ])next($|[-_ ])")
+var pageNumberInPath, _ = regexp.Compile("/page/([0-9]+)/?$")
+
+// GetPagesFromURL downloads the page from the url and follows its next page links,
+// returning the html of at most maxPages pages in order
+func GetPagesFromURL(ctx context.Context, fetcher Fetcher, pageURL string, maxPages int) ([]string, error) {
+	var firstPage, err = GetHTMLFromURL(ctx, fetcher, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages = []string{firstPage}
+	var visited = map[string]bool{normalizePageURL(pageURL): true}
+	var currentURL = pageURL
+	var currentPage = firstPage
+
+	for len(pages) < maxPages {
+		var nextURL = FindNextPageURL(currentPage, currentURL)
+		if nextURL == "" || visited[normalizePageURL(nextURL)] {
+			break
+		}
+		visited[normalizePageURL(nextURL)] = true
+
+		nextPage, err := GetHTMLFromURL(ctx, fetcher, nextURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			// The article is still usable without the remaining pages
+			break
+		}
+
+		pages = append(pages, nextPage)
+		currentURL = nextURL
+		currentPage = nextPage
+	}
+
+	return pages, nil
+}
+
+// ExtractMainInfoFromPages extracts the main content from every page of a multi-page article
+// and merges it, skipping the paragraphs and images repeated on the previous pages
+func ExtractMainInfoFromPages(pages []string) (string, string, []string, error) {
+	if len(pages) == 0 {
+		return "", "", nil, nil
+	}
+
+	var title, text, images, err = ExtractMainInfoFromHTML(pages[0])
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var seenParagraphs = make(map[string]bool)
+	for _, paragraph := range getContentParagraphs(text) {
+		seenParagraphs[strings.TrimSpace(paragraph)] = true
+	}
+
+	var seenImages = make(map[string]bool)
+	for _, image := range images {
+		seenImages[image] = true
+	}
+
+	for _, page := range pages[1:] {
+		var _, pageText, pageImages, err = ExtractMainInfoFromHTML(page)
+		if err != nil {
+			continue
+		}
+
+		for _, paragraph := range getContentParagraphs(pageText) {
+			var trimmedParagraph = strings.TrimSpace(paragraph)
+			if seenParagraphs[trimmedParagraph] {
+				continue
+			}
+
+			seenParagraphs[trimmedParagraph] = true
+			text += "\n\n" + trimmedParagraph
+		}
+
+		for _, image := range pageImages {
+			if !seenImages[image] {
+				seenImages[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+
+	return title, text, images, nil
+}
+
+// FindNextPageURL searches the html for a link to the next page of the article.
+// It returns an absolute url on the same host or an empty string if there is no next page
+func FindNextPageURL(htmlString string, pageURL string) string {
+	var doc, err = html.Parse(strings.NewReader(htmlString))
+	if err != nil {
+		return ""
+	}
+
+	baseURL, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	var candidates = findRelNextLinks(doc)
+	candidates = append(candidates, findPaginationNextLinks(doc, getPageNumber(baseURL))...)
+
+	for _, candidate := range candidates {
+		var nextURL = resolvePageURL(baseURL, candidate)
+		if nextURL != "" && normalizePageURL(nextURL) != normalizePageURL(pageURL) {
+			return nextURL
+		}
+	}
+
+	return ""
+}
+
+// findRelNextLinks returns the targets of <link rel="next"> and <a rel="next"> elements
+func findRelNextLinks(doc *html.Node) []string {
+	var links = append(extractNodes(doc, "link"), extractNodes(doc, "a")...)
+	var targets = []string{}
+
+	for _, link := range links {
+		var rel, _ = getAttribute(link, "rel")
+		var href, hasHref = getAttribute(link, "href")
+		if !hasHref {
+			continue
+		}
+
+		for _, relValue := range strings.Fields(strings.ToLower(rel)) {
+			if relValue == "next" {
+				targets = append(targets, href)
+			}
+		}
+	}
+
+	return targets
+}
+
+// findPaginationNextLinks returns the targets of "next" links and links to the following
+// page number, found inside pagination blocks
+func findPaginationNextLinks(doc *html.Node, currentPage int) []string {
+	var nextLinks = []string{}
+	var numberLinks = []string{}
+	var nextPageNumber = strconv.Itoa(currentPage + 1)
+
+	for _, link := range extractNodes(doc, "a") {
+		var href, hasHref = getAttribute(link, "href")
+		if !hasHref || strings.HasPrefix(href, "#") {
+			continue
+		}
+
+		var linkText = strings.TrimSpace(extractTextFromNode(link))
+		var linkClass, _ = getAttribute(link, "class")
+		var isNextLink = nextLinkText.MatchString(linkText) || nextLinkClass.MatchString(linkClass)
+
+		if !isInPagination(link) {
+			// "Next page" is descriptive enough even outside of a pagination block
+			if strings.EqualFold(linkText, "next page") {
+				nextLinks = append(nextLinks, href)
+			}
+			continue
+		}
+
+		if isNextLink {
+			nextLinks = append(nextLinks, href)
+		} else if linkText == nextPageNumber {
+			numberLinks = append(numberLinks, href)
+		}
+	}
+
+	return append(nextLinks, numberLinks...)
+}
+
+// isInPagination checks if some of the closest ancestors of the node is a pagination block
+func isInPagination(node *html.Node) bool {
+	var depth = 0
+	for parent := node.Parent; parent != nil && depth < 4; parent = parent.Parent {
+		var class, _ = getAttribute(parent, "class")
+		var id, _ = getAttribute(parent, "id")
+		if paginationContainer.MatchString(class) || paginationContainer.MatchString(id) {
+			return true
+		}
+		depth++
+	}
+
+	return false
+}
+
+// getPageNumber reads the current page number from the "page" or "p" query parameter
+// or from a "/page/N" path, defaulting to the first page
+func getPageNumber(pageURL *url.URL) int {
+	var query = pageURL.Query()
+	for _, parameter := range []string{"page", "p"} {
+		if number, err := strconv.Atoi(query.Get(parameter)); err == nil && number > 0 {
+			return number
+		}
+	}
+
+	var match = pageNumberInPath.FindStringSubmatch(pageURL.Path)
+	if match != nil {
+		if number, err := strconv.Atoi(match[1]); err == nil && number > 0 {
+			return number
+		}
+	}
+
+	return 1
+}
+
+// resolvePageURL resolves the link against the base url and keeps it only if it's on the same host
+func resolvePageURL(baseURL *url.URL, link string) string {
+	var linkURL, err = url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+
+	var resolvedURL = baseURL.ResolveReference(linkURL)
+	if resolvedURL.Host != baseURL.Host || (resolvedURL.Scheme != "http" && resolvedURL.Scheme != "https") {
+		return ""
+	}
+
+	return resolvedURL.String()
+}
+
+// normalizePageURL removes the parts of the url, which don't change the page
+func normalizePageURL(pageURL string) string {
+	var parsedURL, err = url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+
+	parsedURL.Fragment = ""
+	return strings.TrimSuffix(parsedURL.String(), "/")
+}
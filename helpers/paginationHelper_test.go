@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindingRelNextPage(t *testing.T) {
+	var testHTML = `<html><head><link rel="next" href="/article?page=2"></head><body></body></html>`
+	var nextURL = FindNextPageURL(testHTML, "http://test.test/article")
+	if nextURL != "http://test.test/article?page=2" {
+		t.Error("Expected rel=next page url but received: ", nextURL)
+	}
+}
+
+func TestFindingPaginationNextLink(t *testing.T) {
+	var testHTML = `<html><body><div class="pagination"><a href="/a?page=1">1</a><a href="/a?page=3">3</a><a href="/a?page=3">Next</a></div></body></html>`
+	var nextURL = FindNextPageURL(testHTML, "http://test.test/a?page=2")
+	if nextURL != "http://test.test/a?page=3" {
+		t.Error("Expected pagination next link but received: ", nextURL)
+	}
+}
+
+func TestFindingPaginationPageNumberLink(t *testing.T) {
+	var testHTML = `<html><body><ul class="pager"><li><a href="/a/page/1/">1</a></li><li><a href="/a/page/2/">2</a></li></ul></body></html>`
+	var nextURL = FindNextPageURL(testHTML, "http://test.test/a/page/1/")
+	if nextURL != "http://test.test/a/page/2/" {
+		t.Error("Expected link to page 2 but received: ", nextURL)
+	}
+}
+
+func TestFindingNextPageIgnoresOtherHosts(t *testing.T) {
+	var testHTML = `<html><head><link rel="next" href="http://other.test/article?page=2"></head><body></body></html>`
+	var nextURL = FindNextPageURL(testHTML, "http://test.test/article")
+	if nextURL != "" {
+		t.Error("Expected no next page but received: ", nextURL)
+	}
+}
+
+func createTestArticlePage(paragraph string, nextLink string) string {
+	var boilerplate = "<p>This paragraph is repeated on every page of the article.</p>"
+	var page = `<html><head><title>Test article title</title>`
+	if nextLink != "" {
+		page += `<link rel="next" href="` + nextLink + `">`
+	}
+
+	return page + `</head><body><div class="article">` + boilerplate + "<p>" + paragraph + "</p></div></body></html>"
+}
+
+func TestGettingPagesFromURL(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Write([]byte(createTestArticlePage("First page text.", "?page=2")))
+		case "2":
+			w.Write([]byte(createTestArticlePage("Second page text.", "?page=3")))
+		default:
+			// The last page links back to the first one, which must not cause a loop
+			w.Write([]byte(createTestArticlePage("Third page text.", "/")))
+		}
+	}))
+	defer server.Close()
+
+	var pages, err = GetPagesFromURL(context.Background(), nil, server.URL+"/", 10)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if len(pages) != 3 {
+		t.Fatal("Expected 3 pages but received ", len(pages))
+	}
+
+	limitedPages, _ := GetPagesFromURL(context.Background(), nil, server.URL+"/", 2)
+	if len(limitedPages) != 2 {
+		t.Error("Expected 2 pages but received ", len(limitedPages))
+	}
+
+	_, text, _, err := ExtractMainInfoFromPages(pages)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if strings.Count(text, "repeated on every page") != 1 {
+		t.Error("Expected the repeated paragraph once but received text: ", text)
+	}
+
+	var firstIndex = strings.Index(text, "First page")
+	var thirdIndex = strings.Index(text, "Third page")
+	if firstIndex < 0 || thirdIndex < firstIndex {
+		t.Error("Expected the pages text in order but received: ", text)
+	}
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Glyph names of the printable ASCII characters, starting from the space (32)
+var asciiGlyphNames = strings.Fields(`space exclam quotedbl numbersign dollar percent ampersand quotesingle
+	parenleft parenright asterisk plus comma hyphen period slash
+	zero one two three four five six seven eight nine colon semicolon less equal greater question
+	at A B C D E F G H I J K L M N O P Q R S T U V W X Y Z bracketleft backslash bracketright asciicircum underscore
+	grave a b c d e f g h i j k l m n o p q r s t u v w x y z braceleft bar braceright asciitilde`)
+
+// Characters 128-159 of WinAnsiEncoding, the rest of the upper half matches Latin-1
+var winAnsiUpperRunes = []rune("€\u0000‚ƒ„…†‡ˆ‰Š‹Œ\u0000Ž\u0000\u0000‘’“”•–—˜™š›œ\u0000žŸ")
+
+// Characters 128-255 of MacRomanEncoding
+var macRomanUpperRunes = []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+
+// Characters of StandardEncoding, which differ from ASCII or are above it
+var standardEncodingRunes = map[int]rune{
+	0x27: '’', 0x60: '‘', 0xa1: '¡', 0xa2: '¢', 0xa3: '£', 0xa4: '⁄', 0xa5: '¥', 0xa6: 'ƒ', 0xa7: '§',
+	0xa8: '¤', 0xa9: '\'', 0xaa: '“', 0xab: '«', 0xac: '‹', 0xad: '›', 0xae: 'ﬁ', 0xaf: 'ﬂ',
+	0xb1: '–', 0xb2: '†', 0xb3: '‡', 0xb4: '·', 0xb6: '¶', 0xb7: '•', 0xb8: '‚', 0xb9: '„',
+	0xba: '”', 0xbb: '»', 0xbc: '…', 0xbd: '‰', 0xbf: '¿', 0xc1: '`', 0xc2: '´', 0xc3: 'ˆ',
+	0xc4: '˜', 0xc5: '¯', 0xc6: '˘', 0xc7: '˙', 0xc8: '¨', 0xca: '˚', 0xcb: '¸', 0xcd: '˝',
+	0xce: '˛', 0xcf: 'ˇ', 0xd0: '—', 0xe1: 'Æ', 0xe3: 'ª', 0xe8: 'Ł', 0xe9: 'Ø', 0xea: 'Œ',
+	0xeb: 'º', 0xf1: 'æ', 0xf5: 'ı', 0xf8: 'ł', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß',
+}
+
+// Glyph names outside of ASCII, which are common in /Differences arrays
+var glyphNames = map[string]rune{
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”', "quotesinglbase": '‚',
+	"quotedblbase": '„', "guillemotleft": '«', "guillemotright": '»', "guilsinglleft": '‹', "guilsinglright": '›',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…', "dagger": '†', "daggerdbl": '‡',
+	"periodcentered": '·', "minus": '−', "degree": '°', "copyright": '©', "registered": '®', "trademark": '™',
+	"section": '§', "paragraph": '¶', "Euro": '€', "sterling": '£', "yen": '¥', "cent": '¢', "florin": 'ƒ',
+	"perthousand": '‰', "exclamdown": '¡', "questiondown": '¿', "nbspace": ' ', "fraction": '⁄',
+	"fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ', "germandbls": 'ß', "dotlessi": 'ı',
+	"AE": 'Æ', "ae": 'æ', "OE": 'Œ', "oe": 'œ', "Oslash": 'Ø', "oslash": 'ø', "Lslash": 'Ł', "lslash": 'ł',
+	"multiply": '×', "divide": '÷', "plusminus": '±', "mu": 'µ', "ordfeminine": 'ª', "ordmasculine": 'º',
+}
+
+// Accented latin letters are named by the letter followed by the accent
+var accentRunes = map[string]string{
+	"grave":      "ÀàÈèÌìÒòÙù",
+	"acute":      "ÁáÉéÍíÓóÚúÝý",
+	"circumflex": "ÂâÊêÎîÔôÛû",
+	"dieresis":   "ÄäËëÏïÖöÜüŸÿ",
+	"tilde":      "ÃãÑñÕõ",
+	"ring":       "Åå",
+	"cedilla":    "Çç",
+	"caron":      "ŠšŽžČčŘřĚě",
+}
+
+func standardPDFEncoding() [256]rune {
+	var encoding [256]rune
+	for i := 32; i < 127; i++ {
+		encoding[i] = rune(i)
+	}
+	for code, char := range standardEncodingRunes {
+		encoding[code] = char
+	}
+	return encoding
+}
+
+func winAnsiPDFEncoding() [256]rune {
+	var encoding [256]rune
+	for i := 32; i < 256; i++ {
+		encoding[i] = rune(i)
+	}
+	for i, char := range winAnsiUpperRunes {
+		encoding[128+i] = char
+	}
+	encoding[127] = 0
+	return encoding
+}
+
+func macRomanPDFEncoding() [256]rune {
+	var encoding [256]rune
+	for i := 32; i < 127; i++ {
+		encoding[i] = rune(i)
+	}
+	for i, char := range macRomanUpperRunes {
+		encoding[128+i] = char
+	}
+	return encoding
+}
+
+// glyphNameToRune returns the character for a glyph name from a font /Differences array,
+// or 0 if the name is unknown
+func glyphNameToRune(name string) rune {
+	// Suffixes like ".sc" or ".alt" mark variants of the same character
+	if dot := strings.Index(name, "."); dot > 0 {
+		name = name[:dot]
+	}
+
+	for i, asciiName := range asciiGlyphNames {
+		if asciiName == name {
+			return rune(32 + i)
+		}
+	}
+
+	if char, exists := glyphNames[name]; exists {
+		return char
+	}
+
+	for accent, chars := range accentRunes {
+		if len(name) == len(accent)+1 && strings.HasSuffix(name, accent) {
+			for _, char := range chars {
+				if strings.EqualFold(removeAccent(char), name[:1]) && isSameCase(char, name[0]) {
+					return char
+				}
+			}
+		}
+	}
+
+	for _, prefix := range []string{"uni", "u"} {
+		if strings.HasPrefix(name, prefix) && len(name) >= len(prefix)+4 {
+			if code, err := strconv.ParseUint(name[len(prefix):len(prefix)+4], 16, 32); err == nil {
+				return rune(code)
+			}
+		}
+	}
+
+	return 0
+}
+
+// removeAccent returns the base latin letter of the accented letter
+func removeAccent(char rune) string {
+	const accented = "ÀàÈèÌìÒòÙùÁáÉéÍíÓóÚúÝýÂâÊêÎîÔôÛûÄäËëÏïÖöÜüŸÿÃãÑñÕõÅåÇçŠšŽžČčŘřĚě"
+	const base = "AaEeIiOoUuAaEeIiOoUuYyAaEeIiOoUuAaEeIiOoUuYyAaNnOoAaCcSsZzCcRrEe"
+
+	var baseRunes = []rune(base)
+	for i, accentedChar := range []rune(accented) {
+		if accentedChar == char {
+			return string(baseRunes[i])
+		}
+	}
+	return ""
+}
+
+func isSameCase(char rune, letter byte) bool {
+	return (strings.ToUpper(string(char)) == string(char)) == (letter >= 'A' && letter <= 'Z')
+}
@@ -0,0 +1,238 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// Decoders of the image formats written to pdf files
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/signintech/gopdf"
+	_ "golang.org/x/image/webp"
+)
+
+// Images with width and height summing up to this number of pixels are icons and they are not written
+const pdfMinImageSize = 50
+
+// Images with more pixels than this are not decoded, because their decoding could use gigabytes of memory
+const maxImagePixels = 50 * 1000 * 1000
+
+// pdfImageCaptionSize is the font size of the image captions
+const pdfImageCaptionSize = 9
+
+// writeImages writes up to MaxImages of the images, scaled to fit between the margins and keeping their aspect ratio.
+// The images are decoded in memory. Images, which can't be loaded or decoded, are skipped
+func (w *pdfWriter) writeImages(ctx context.Context, imageURLs []string, fetcher Fetcher) error {
+	var written = 0
+	for _, imageURL := range imageURLs {
+		if written >= w.style.MaxImages {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var data, err = loadImage(ctx, fetcher, w.style.ImageDir, w.style.AllowLocalImages, imageURL)
+		if err != nil {
+			continue
+		}
+
+		decoded, format, err := decodeImage(data)
+		if err != nil {
+			continue
+		}
+
+		var bounds = decoded.Bounds()
+		if bounds.Dx()+bounds.Dy() <= pdfMinImageSize {
+			continue
+		}
+
+		var captionHeight = 0.0
+		if w.style.ImageCaptions {
+			captionHeight = pdfImageCaptionSize * w.style.LineSpacing
+		}
+
+		// The image is never taller than the space of a whole page, so it always fits on the next page
+		var maxHeight = w.contentBottom() - w.style.MarginTop - captionHeight
+		var width, height = scaleImage(bounds.Dx(), bounds.Dy(), w.contentWidth(), maxHeight)
+		w.ensureSpace(height + captionHeight)
+
+		err = w.drawImage(data, format, decoded, &gopdf.Rect{W: width, H: height})
+		if err != nil {
+			continue
+		}
+		w.y += height
+		written++
+
+		if w.style.ImageCaptions {
+			w.y += 2
+			if err := w.writeImageCaption(written, imageURL); err != nil {
+				return err
+			}
+			w.y += captionHeight
+		}
+		w.y += w.style.FontSize / 2
+	}
+
+	return nil
+}
+
+// decodeImage decodes the image after checking, from its header, that it's not too large
+func decodeImage(data []byte) (image.Image, string, error) {
+	var config, _, err = image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, "", errors.New("The image is too large: " + strconv.Itoa(config.Width) + "x" + strconv.Itoa(config.Height))
+	}
+
+	return image.Decode(bytes.NewReader(data))
+}
+
+// drawImage writes jpeg and png images as they are and other formats, like gif and webp, converted to png
+func (w *pdfWriter) drawImage(data []byte, format string, decoded image.Image, rect *gopdf.Rect) error {
+	if format == "jpeg" || format == "png" {
+		if holder, err := gopdf.ImageHolderByBytes(data); err == nil {
+			if err = w.pdf.ImageByHolder(holder, w.style.MarginLeft, w.y, rect); err == nil {
+				return nil
+			}
+		}
+	}
+
+	return w.pdf.ImageFrom(decoded, w.style.MarginLeft, w.y, rect)
+}
+
+func (w *pdfWriter) writeImageCaption(number int, imageURL string) error {
+	var err = w.pdf.SetFont(pdfBodyFont, "", pdfImageCaptionSize)
+	if err != nil {
+		return err
+	}
+
+	var caption = "Figure " + strconv.Itoa(number) + ": " + imageURL
+	w.pdf.SetXY(w.style.MarginLeft, w.y)
+	return w.pdf.Cell(nil, w.fitText(caption, w.contentWidth()))
+}
+
+// scaleImage returns the size of the image in points, scaled down to fit in the width and the height.
+// Every pixel is a point, so small images are not enlarged
+func scaleImage(pixelWidth int, pixelHeight int, maxWidth float64, maxHeight float64) (float64, float64) {
+	var width, height = float64(pixelWidth), float64(pixelHeight)
+	var scale = math.Min(1, math.Min(maxWidth/width, maxHeight/height))
+	return width * scale, height * scale
+}
+
+// ImageOptions configure the images embedded in docx and epub files. MaxImages limits their number,
+// zero embeds all images and a negative value none. ImageDir and AllowLocalImages work like the ones of PDFStyle
+type ImageOptions struct {
+	MaxImages        int
+	ImageDir         string
+	AllowLocalImages bool
+}
+
+// embeddedImage is an image embedded in docx and epub files. Its data is jpeg or png and its size is in pixels
+type embeddedImage struct {
+	data   []byte
+	format string
+	width  int
+	height int
+	source string
+}
+
+// fileName returns the name of the image file with the given number in the document
+func (i embeddedImage) fileName(number int) string {
+	return "image" + strconv.Itoa(number) + "." + i.format
+}
+
+// loadEmbeddedImages loads and decodes the images allowed by the options. Jpeg and png images are kept as they are
+// and the other formats are converted to png. Icons and images, which can't be loaded, are skipped
+func loadEmbeddedImages(ctx context.Context, fetcher Fetcher, imageURLs []string, options ImageOptions) ([]embeddedImage, error) {
+	var images = []embeddedImage{}
+	for _, imageURL := range imageURLs {
+		if options.MaxImages < 0 || (options.MaxImages > 0 && len(images) >= options.MaxImages) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var data, err = loadImage(ctx, fetcher, options.ImageDir, options.AllowLocalImages, imageURL)
+		if err != nil {
+			continue
+		}
+
+		decoded, format, err := decodeImage(data)
+		if err != nil || decoded.Bounds().Dx()+decoded.Bounds().Dy() <= pdfMinImageSize {
+			continue
+		}
+
+		if format != "jpeg" && format != "png" {
+			var converted bytes.Buffer
+			if err := png.Encode(&converted, decoded); err != nil {
+				continue
+			}
+			data, format = converted.Bytes(), "png"
+		}
+
+		images = append(images, embeddedImage{
+			data:   data,
+			format: format,
+			width:  decoded.Bounds().Dx(),
+			height: decoded.Bounds().Dy(),
+			source: imageURL,
+		})
+	}
+
+	return images, nil
+}
+
+// loadImage loads the image of the url. Summary images usually come from scraped pages, so only http and https
+// images are loaded by default. Remote images are read from the image directory if it has a file with their name,
+// or they are downloaded with the fetcher. Local images, given as path or file url, are read from the image
+// directory by their file name, or from anywhere on the disk only if allowLocal is set
+func loadImage(ctx context.Context, fetcher Fetcher, imageDir string, allowLocal bool, imageURL string) ([]byte, error) {
+	var parsedURL, err = url.Parse(imageURL)
+	// Paths without scheme and windows paths like C:\images\a.png are local files
+	var isLocal = err != nil || parsedURL.Scheme == "" || len(parsedURL.Scheme) == 1 || parsedURL.Scheme == "file"
+	if !isLocal && !isWebURL(imageURL) {
+		return nil, errors.New("Unsupported image url: " + imageURL)
+	}
+
+	if isLocal && allowLocal {
+		if err == nil && parsedURL.Scheme == "file" {
+			return ioutil.ReadFile(filepath.FromSlash(parsedURL.Path))
+		}
+		return ioutil.ReadFile(imageURL)
+	}
+
+	if imageDir != "" {
+		var name = path.Base(filepath.ToSlash(imageURL))
+		if !isLocal {
+			name = path.Base(parsedURL.Path)
+		}
+		if name != "/" && name != "." && !strings.HasPrefix(name, "..") {
+			if data, err := ioutil.ReadFile(filepath.Join(imageDir, name)); err == nil {
+				return data, nil
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	if isLocal {
+		return nil, errors.New("Local images are not allowed: " + imageURL)
+	}
+	return getFetcher(fetcher).Fetch(ctx, imageURL)
+}
@@ -0,0 +1,163 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mapFetcher returns the bodies of the known urls and an error for all others
+type mapFetcher map[string][]byte
+
+func (f mapFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if body, found := f[url]; found {
+		return body, nil
+	}
+	return nil, errors.New("Unknown url")
+}
+
+func createTestImage(t *testing.T, width int, height int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	var picture = image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	var content bytes.Buffer
+	if err := encode(&content, picture); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	return content.Bytes()
+}
+
+func TestScalingImage(t *testing.T) {
+	var width, height = scaleImage(1000, 500, 400, 700)
+	if width != 400 || height != 200 {
+		t.Error("Expected image scaled to 400x200 but received: ", width, height)
+	}
+
+	width, height = scaleImage(300, 900, 400, 450)
+	if width != 150 || height != 450 {
+		t.Error("Expected image scaled to 150x450 but received: ", width, height)
+	}
+
+	width, height = scaleImage(100, 80, 400, 450)
+	if width != 100 || height != 80 {
+		t.Error("Expected small image not to be enlarged but received: ", width, height)
+	}
+}
+
+func TestRenderingPDFImages(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "pdf-images-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var encodePNG = func(content *bytes.Buffer, picture image.Image) error { return png.Encode(content, picture) }
+	var encodeGIF = func(content *bytes.Buffer, picture image.Image) error { return gif.Encode(content, picture, nil) }
+
+	var localPath = filepath.Join(directory, "local.gif")
+	ioutil.WriteFile(localPath, createTestImage(t, 120, 60, encodeGIF), 0644)
+	ioutil.WriteFile(filepath.Join(directory, "offline.png"), createTestImage(t, 2000, 1000, encodePNG), 0644)
+
+	var fetcher = mapFetcher{
+		"http://test.test/icon.png":    createTestImage(t, 16, 16, encodePNG),
+		"http://test.test/broken.jpg":  []byte("not an image"),
+		"http://test.test/ignored.png": createTestImage(t, 100, 100, encodePNG),
+	}
+
+	var style = DefaultPDFStyle()
+	style.MaxImages = 2
+	style.ImageDir = directory
+	var summary = &Summary{
+		Title: "Images",
+		Text:  "The summary has images.",
+		Images: []string{"http://test.test/icon.png", "http://test.test/broken.jpg", "http://test.test/missing.png",
+			localPath, "http://test.test/images/offline.png", "http://test.test/ignored.png"},
+	}
+
+	var content bytes.Buffer
+	err = PDFRenderer{Fetcher: fetcher, Style: &style}.Render(&content, summary)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if count := bytes.Count(content.Bytes(), []byte("/Subtype /Image")); count != 2 {
+		t.Error("Expected 2 images but received: ", count)
+	}
+
+	var _, text, _ = ExtractTextFromPDF(content.Bytes())
+	for _, caption := range []string{"Figure 1", "Figure 2", "offline.png"} {
+		if !bytes.Contains([]byte(text), []byte(caption)) {
+			t.Error("Expected caption ", caption, " but received: ", text)
+		}
+	}
+}
+
+func TestLoadingLocalImagesOnlyWhenAllowed(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "pdf-images-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var localPath = filepath.Join(directory, "local.png")
+	ioutil.WriteFile(localPath, []byte("image"), 0644)
+	var fileURL = "file://" + filepath.ToSlash(localPath)
+	var fetcher = mapFetcher{"https://test.test/remote.png": []byte("remote")}
+
+	for _, imageURL := range []string{localPath, fileURL, "javascript:alert(1)", "ftp://test.test/remote.png"} {
+		if _, err := loadImage(context.Background(), fetcher, "", false, imageURL); err == nil {
+			t.Error("Expected error for ", imageURL, " but received none")
+		}
+	}
+
+	for _, imageURL := range []string{localPath, fileURL} {
+		if data, err := loadImage(context.Background(), fetcher, "", true, imageURL); err != nil || string(data) != "image" {
+			t.Error("Expected allowed local image ", imageURL, " but received: ", err)
+		}
+		if data, err := loadImage(context.Background(), fetcher, directory, false, imageURL); err != nil || string(data) != "image" {
+			t.Error("Expected local image ", imageURL, " from the image directory but received: ", err)
+		}
+	}
+
+	if data, err := loadImage(context.Background(), fetcher, "", false, "https://test.test/remote.png"); err != nil || string(data) != "remote" {
+		t.Error("Expected downloaded image but received: ", err)
+	}
+
+	images, err := loadEmbeddedImages(context.Background(), fetcher, []string{localPath, fileURL}, ImageOptions{MaxImages: 2})
+	if err != nil || len(images) != 0 {
+		t.Error("Expected no local images to be embedded but received: ", images, err)
+	}
+}
+
+func TestSkippingTooLargeImages(t *testing.T) {
+	var encodePNG = func(content *bytes.Buffer, picture image.Image) error { return png.Encode(content, picture) }
+	var largeImage = createTestImage(t, 100, 100, encodePNG)
+	// The png header claims 100000x100000 pixels, which would need gigabytes of memory for decoding
+	binary.BigEndian.PutUint32(largeImage[16:20], 100000)
+	binary.BigEndian.PutUint32(largeImage[20:24], 100000)
+	binary.BigEndian.PutUint32(largeImage[29:33], crc32.ChecksumIEEE(largeImage[12:29]))
+
+	if _, _, err := decodeImage(largeImage); err == nil {
+		t.Error("Expected error for too large image but received none")
+	}
+
+	var fetcher = mapFetcher{
+		"https://test.test/large.png": largeImage,
+		"https://test.test/small.png": createTestImage(t, 100, 100, encodePNG),
+	}
+	images, err := loadEmbeddedImages(context.Background(), fetcher, []string{"https://test.test/large.png", "https://test.test/small.png"}, ImageOptions{})
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if len(images) != 1 || images[0].source != "https://test.test/small.png" {
+		t.Error("Expected only the small image but received: ", len(images))
+	}
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"math"
+	"strings"
+)
+
+// LineBreaking is the way the paragraphs of the pdf files are broken into lines
+type LineBreaking int
+
+// Line breaking algorithms. LineBreakingGreedy fills every line as much as possible and
+// LineBreakingOptimal chooses the breaks, which make the lines of the paragraph most even, like Knuth and Plass
+const (
+	LineBreakingGreedy LineBreaking = iota
+	LineBreakingOptimal
+)
+
+// textMeasurer returns the width of the text with the current font
+type textMeasurer func(text string) (float64, error)
+
+// layoutLine is a line of a paragraph. Last is set for the last line, which is never justified
+type layoutLine struct {
+	Words  []string
+	Widths []float64
+	Width  float64
+	Last   bool
+}
+
+// layoutParagraph breaks the paragraph into lines, which fit in the width.
+// Words longer than the width are hyphenated
+func layoutParagraph(paragraph string, width float64, measure textMeasurer, breaking LineBreaking) ([]layoutLine, error) {
+	var spaceWidth, err = measure(" ")
+	if err != nil {
+		return nil, err
+	}
+
+	var words = []string{}
+	var widths = []float64{}
+	for _, word := range strings.Fields(paragraph) {
+		var pieces, err = hyphenateWord(word, width, measure)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, piece := range pieces {
+			var pieceWidth, err = measure(piece)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, piece)
+			widths = append(widths, pieceWidth)
+		}
+	}
+
+	if len(words) == 0 {
+		return []layoutLine{}, nil
+	}
+
+	var breaks []int
+	if breaking == LineBreakingOptimal {
+		breaks = optimalLineBreaks(widths, spaceWidth, width)
+	} else {
+		breaks = greedyLineBreaks(widths, spaceWidth, width)
+	}
+
+	var lines = []layoutLine{}
+	var start = 0
+	for _, end := range breaks {
+		var line = layoutLine{Words: words[start:end], Widths: widths[start:end], Width: lineWidth(widths[start:end], spaceWidth)}
+		lines = append(lines, line)
+		start = end
+	}
+	lines[len(lines)-1].Last = true
+
+	return lines, nil
+}
+
+// hyphenateWord splits the word into pieces, which fit in the width. All pieces but the last end with a hyphen
+func hyphenateWord(word string, width float64, measure textMeasurer) ([]string, error) {
+	var wordWidth, err = measure(word)
+	if err != nil || wordWidth <= width {
+		return []string{word}, err
+	}
+
+	var pieces = []string{}
+	var runes = []rune(word)
+	for len(runes) > 0 {
+		var restWidth, err = measure(string(runes))
+		if err != nil {
+			return nil, err
+		}
+		if restWidth <= width {
+			break
+		}
+
+		// The longest prefix with a hyphen, which fits, but at least one letter
+		var length = 1
+		for length < len(runes)-1 {
+			var prefixWidth, err = measure(string(runes[:length+1]) + "-")
+			if err != nil {
+				return nil, err
+			}
+			if prefixWidth > width {
+				break
+			}
+			length++
+		}
+
+		pieces = append(pieces, string(runes[:length])+"-")
+		runes = runes[length:]
+	}
+
+	if len(runes) > 0 {
+		pieces = append(pieces, string(runes))
+	}
+	return pieces, nil
+}
+
+func lineWidth(widths []float64, spaceWidth float64) float64 {
+	var width = spaceWidth * float64(len(widths)-1)
+	for _, wordWidth := range widths {
+		width += wordWidth
+	}
+	return width
+}
+
+// greedyLineBreaks returns the end of every line, putting as many words as possible on each line
+func greedyLineBreaks(widths []float64, spaceWidth float64, width float64) []int {
+	var breaks = []int{}
+	var current = 0.0
+	for i, wordWidth := range widths {
+		if i > 0 && current+spaceWidth+wordWidth > width && current > 0 {
+			breaks = append(breaks, i)
+			current = 0
+		}
+
+		if current > 0 {
+			current += spaceWidth
+		}
+		current += wordWidth
+	}
+
+	return append(breaks, len(widths))
+}
+
+// optimalLineBreaks returns the end of every line, minimizing the sum of the squared free space
+// at the end of the lines. The last line is free, like in the Knuth-Plass algorithm
+func optimalLineBreaks(widths []float64, spaceWidth float64, width float64) []int {
+	var count = len(widths)
+	var costs = make([]float64, count+1)
+	var previous = make([]int, count+1)
+	for i := 1; i <= count; i++ {
+		costs[i] = math.Inf(1)
+	}
+
+	for end := 1; end <= count; end++ {
+		var currentWidth = -spaceWidth
+		for start := end - 1; start >= 0; start-- {
+			currentWidth += spaceWidth + widths[start]
+			// A single word always makes a line, even if it's too wide
+			if currentWidth > width && start < end-1 {
+				break
+			}
+
+			var cost = 0.0
+			if end < count {
+				cost = math.Pow(math.Max(width-currentWidth, 0), 2)
+			}
+
+			if costs[start]+cost < costs[end] {
+				costs[end] = costs[start] + cost
+				previous[end] = start
+			}
+		}
+	}
+
+	var breaks = []int{}
+	for end := count; end > 0; end = previous[end] {
+		breaks = append([]int{end}, breaks...)
+	}
+	return breaks
+}
+
+// linesOnPage returns how many of the remaining lines of a paragraph go on the current page, which has space
+// for the given number of lines. At least minLines lines are kept together at the bottom and at the top of
+// the pages, so a paragraph doesn't leave a single line behind. The result is 0, if the paragraph must continue
+// on the next page. At the top of a page the result is never 0, because the next page has no more space
+func linesOnPage(remaining int, available int, minLines int, pageStart bool) int {
+	if available >= remaining {
+		return remaining
+	}
+	if available <= 0 {
+		if pageStart {
+			return 1
+		}
+		return 0
+	}
+
+	var lines = available
+	if remaining-lines < minLines {
+		lines = remaining - minLines
+	}
+	if lines < minLines {
+		if pageStart {
+			return available
+		}
+		return 0
+	}
+	return lines
+}
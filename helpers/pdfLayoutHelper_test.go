@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// measureRunes measures every letter as 1 point wide
+func measureRunes(text string) (float64, error) {
+	return float64(utf8.RuneCountInString(text)), nil
+}
+
+func getLineTexts(lines []layoutLine) []string {
+	var texts = []string{}
+	for _, line := range lines {
+		var text = ""
+		for i, word := range line.Words {
+			if i > 0 {
+				text += " "
+			}
+			text += word
+		}
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+func TestLayoutParagraphGreedyAndOptimal(t *testing.T) {
+	var paragraph = "aaa bb cc ddddd"
+
+	greedy, err := layoutParagraph(paragraph, 6, measureRunes, LineBreakingGreedy)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if texts := getLineTexts(greedy); !reflect.DeepEqual(texts, []string{"aaa bb", "cc", "ddddd"}) {
+		t.Error("Expected greedy lines but received: ", texts)
+	}
+
+	optimal, err := layoutParagraph(paragraph, 6, measureRunes, LineBreakingOptimal)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if texts := getLineTexts(optimal); !reflect.DeepEqual(texts, []string{"aaa", "bb cc", "ddddd"}) {
+		t.Error("Expected even lines but received: ", texts)
+	}
+	if !optimal[2].Last || optimal[1].Last || optimal[1].Width != 5 {
+		t.Error("Expected widths and last line to be set but received: ", optimal)
+	}
+}
+
+func TestLayoutParagraphHyphenation(t *testing.T) {
+	var lines, err = layoutParagraph("go abcdefghijkl end", 5, measureRunes, LineBreakingGreedy)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedLines = []string{"go", "abcd-", "efgh-", "ijkl", "end"}
+	if texts := getLineTexts(lines); !reflect.DeepEqual(texts, expectedLines) {
+		t.Error("Expected hyphenated lines ", expectedLines, " but received: ", texts)
+	}
+}
+
+func TestLinesOnPage(t *testing.T) {
+	var cases = []struct {
+		remaining int
+		available int
+		pageStart bool
+		expected  int
+	}{
+		{5, 10, false, 5},
+		{5, 1, false, 0},
+		{5, 4, false, 3},
+		{3, 2, false, 0},
+		{3, 2, true, 2},
+		{5, 0, true, 1},
+	}
+
+	for _, testCase := range cases {
+		var lines = linesOnPage(testCase.remaining, testCase.available, 2, testCase.pageStart)
+		if lines != testCase.expected {
+			t.Error("Expected ", testCase.expected, " lines for ", testCase, " but received: ", lines)
+		}
+	}
+}
@@ -0,0 +1,591 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+)
+
+// PDF objects are represented with these types, together with float64 for numbers,
+// bool for booleans and nil for null
+type pdfName string
+type pdfString []byte
+type pdfKeyword string
+type pdfArray []interface{}
+type pdfDict map[pdfName]interface{}
+
+type pdfRef struct {
+	number     int
+	generation int
+}
+
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+type pdfDocument struct {
+	objects map[int]interface{}
+	trailer pdfDict
+}
+
+var pdfObjectStart, _ = regexp.Compile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfMaxNestingDepth limits the nesting of arrays and dictionaries, so malicious files can't exhaust the stack
+const pdfMaxNestingDepth = 256
+
+// pdfLexer reads tokens and objects from pdf files and content streams
+type pdfLexer struct {
+	data     []byte
+	position int
+	// depth is the number of arrays and dictionaries being read. err stops the reading when they are nested too deeply
+	depth int
+	err   error
+}
+
+func isPDFWhitespace(char byte) bool {
+	return char == ' ' || char == '\n' || char == '\r' || char == '\t' || char == '\f' || char == 0
+}
+
+func isPDFDelimiter(char byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), char) >= 0
+}
+
+func (l *pdfLexer) skipWhitespace() {
+	for l.position < len(l.data) {
+		var char = l.data[l.position]
+		if char == '%' {
+			for l.position < len(l.data) && l.data[l.position] != '\n' && l.data[l.position] != '\r' {
+				l.position++
+			}
+		} else if isPDFWhitespace(char) {
+			l.position++
+		} else {
+			return
+		}
+	}
+}
+
+// nextToken returns the next value or keyword. Delimiters of arrays and dictionaries
+// are returned as keywords, e.g. "[" and ">>". It returns nil and false at the end of the data
+func (l *pdfLexer) nextToken() (interface{}, bool) {
+	l.skipWhitespace()
+	if l.position >= len(l.data) {
+		return nil, false
+	}
+
+	var char = l.data[l.position]
+	switch {
+	case char == '/':
+		return l.readName(), true
+	case char == '(':
+		return l.readLiteralString(), true
+	case char == '<':
+		if l.position+1 < len(l.data) && l.data[l.position+1] == '<' {
+			l.position += 2
+			return pdfKeyword("<<"), true
+		}
+		return l.readHexString(), true
+	case char == '>':
+		l.position++
+		if l.position < len(l.data) && l.data[l.position] == '>' {
+			l.position++
+		}
+		return pdfKeyword(">>"), true
+	case char == '[' || char == ']' || char == '{' || char == '}' || char == ')':
+		l.position++
+		return pdfKeyword(string(char)), true
+	}
+
+	var start = l.position
+	for l.position < len(l.data) && !isPDFWhitespace(l.data[l.position]) && !isPDFDelimiter(l.data[l.position]) {
+		l.position++
+	}
+
+	var word = string(l.data[start:l.position])
+	if number, err := strconv.ParseFloat(word, 64); err == nil {
+		return number, true
+	}
+
+	switch word {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+
+	return pdfKeyword(word), true
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.position++ // skip the slash
+	var name bytes.Buffer
+	for l.position < len(l.data) && !isPDFWhitespace(l.data[l.position]) && !isPDFDelimiter(l.data[l.position]) {
+		var char = l.data[l.position]
+		if char == '#' && l.position+2 < len(l.data) {
+			if decoded, err := hex.DecodeString(string(l.data[l.position+1 : l.position+3])); err == nil {
+				name.Write(decoded)
+				l.position += 3
+				continue
+			}
+		}
+		name.WriteByte(char)
+		l.position++
+	}
+
+	return pdfName(name.String())
+}
+
+func (l *pdfLexer) readLiteralString() pdfString {
+	l.position++ // skip the opening bracket
+	var result bytes.Buffer
+	var depth = 1
+
+	for l.position < len(l.data) {
+		var char = l.data[l.position]
+		l.position++
+
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(result.Bytes())
+			}
+		case '\\':
+			if l.position >= len(l.data) {
+				continue
+			}
+			char = l.data[l.position]
+			l.position++
+			switch char {
+			case 'n':
+				char = '\n'
+			case 'r':
+				char = '\r'
+			case 't':
+				char = '\t'
+			case 'b':
+				char = '\b'
+			case 'f':
+				char = '\f'
+			case '\r':
+				// A backslash at the end of the line continues the string on the next line
+				if l.position < len(l.data) && l.data[l.position] == '\n' {
+					l.position++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if char >= '0' && char <= '7' {
+					var value = int(char - '0')
+					for i := 0; i < 2 && l.position < len(l.data) && l.data[l.position] >= '0' && l.data[l.position] <= '7'; i++ {
+						value = value*8 + int(l.data[l.position]-'0')
+						l.position++
+					}
+					char = byte(value)
+				}
+			}
+		}
+
+		result.WriteByte(char)
+	}
+
+	return pdfString(result.Bytes())
+}
+
+func (l *pdfLexer) readHexString() pdfString {
+	l.position++ // skip the opening bracket
+	var digits []byte
+	for l.position < len(l.data) && l.data[l.position] != '>' {
+		var char = l.data[l.position]
+		if !isPDFWhitespace(char) {
+			digits = append(digits, char)
+		}
+		l.position++
+	}
+	l.position++ // skip the closing bracket
+
+	// A missing last digit is assumed to be 0
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	var decoded, _ = hex.DecodeString(string(digits))
+	return pdfString(decoded)
+}
+
+// nextObject reads a complete object, including arrays, dictionaries and references.
+// Keywords which are not part of an object, e.g. content stream operators, are returned as they are
+func (l *pdfLexer) nextObject() (interface{}, bool) {
+	var token, ok = l.nextToken()
+	if !ok || l.err != nil {
+		return nil, false
+	}
+
+	if token == pdfKeyword("[") || token == pdfKeyword("<<") {
+		if l.depth >= pdfMaxNestingDepth {
+			l.err = errors.New("The pdf objects are nested too deeply")
+			return nil, false
+		}
+		l.depth++
+		defer func() { l.depth-- }()
+	}
+
+	switch token {
+	case pdfKeyword("["):
+		var array = pdfArray{}
+		for {
+			var element, ok = l.nextObject()
+			if l.err != nil {
+				return nil, false
+			}
+			if !ok || element == pdfKeyword("]") {
+				return array, true
+			}
+			array = append(array, element)
+		}
+	case pdfKeyword("<<"):
+		var dict = pdfDict{}
+		for {
+			var key, ok = l.nextObject()
+			if l.err != nil {
+				return nil, false
+			}
+			if !ok || key == pdfKeyword(">>") {
+				return dict, true
+			}
+
+			value, ok := l.nextObject()
+			if l.err != nil {
+				return nil, false
+			}
+			if !ok {
+				return dict, true
+			}
+
+			if name, isName := key.(pdfName); isName {
+				dict[name] = value
+			}
+		}
+	}
+
+	// Two integers followed by R are an indirect reference
+	if number, isNumber := token.(float64); isNumber && number == float64(int(number)) {
+		var savedPosition = l.position
+		var generation, hasGeneration = l.nextToken()
+		var keyword, hasKeyword = l.nextToken()
+		if generationNumber, isNumber := generation.(float64); hasGeneration && hasKeyword && isNumber && keyword == pdfKeyword("R") {
+			return pdfRef{number: int(number), generation: int(generationNumber)}, true
+		}
+		l.position = savedPosition
+	}
+
+	return token, true
+}
+
+// pdfHeaderPattern is the pdf header with the version, like "%PDF-1.7"
+var pdfHeaderPattern = regexp.MustCompile(`%PDF-\d\.\d`)
+
+// pdfHeaderSearchLength is how far into the data the pdf readers look for the header
+const pdfHeaderSearchLength = 1024
+
+// findPDFHeader returns the offset of the pdf header or -1 if the data is not a pdf document. Like in the pdf readers,
+// the header can follow some junk bytes, but it must be in the first 1024 bytes and it must start a line,
+// so texts mentioning the header are not taken for pdf documents
+func findPDFHeader(data []byte) int {
+	var header = data
+	if len(header) > pdfHeaderSearchLength {
+		header = header[:pdfHeaderSearchLength]
+	}
+
+	for _, match := range pdfHeaderPattern.FindAllIndex(header, -1) {
+		if match[0] == 0 || header[match[0]-1] == '\n' || header[match[0]-1] == '\r' {
+			return match[0]
+		}
+	}
+
+	return -1
+}
+
+// parsePDF reads all objects of the pdf file. Instead of relying on the cross-reference
+// table, which is often broken, the file is scanned for object definitions
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if findPDFHeader(data) < 0 {
+		return nil, errors.New("The data is not a pdf document")
+	}
+
+	var document = &pdfDocument{objects: make(map[int]interface{}), trailer: pdfDict{}}
+	var objectStreams = []*pdfStream{}
+
+	// Stream data may contain bytes looking like object definitions, so it's skipped
+	var skipUntil = 0
+	for _, match := range pdfObjectStart.FindAllSubmatchIndex(data, -1) {
+		if match[0] < skipUntil {
+			continue
+		}
+
+		var number, _ = strconv.Atoi(string(data[match[2]:match[3]]))
+		var lexer = &pdfLexer{data: data, position: match[1]}
+		var object, ok = lexer.nextObject()
+		if lexer.err != nil {
+			return nil, lexer.err
+		}
+		if !ok {
+			continue
+		}
+
+		if dict, isDict := object.(pdfDict); isDict {
+			var savedPosition = lexer.position
+			var keyword, _ = lexer.nextToken()
+			if keyword == pdfKeyword("stream") {
+				var streamData, streamEnd = readStreamData(data, lexer.position, dict)
+				var stream = &pdfStream{dict: dict, data: streamData}
+				skipUntil = streamEnd
+				object = stream
+				if dict["Type"] == pdfName("ObjStm") {
+					objectStreams = append(objectStreams, stream)
+				}
+				if dict["Type"] == pdfName("XRef") {
+					mergeTrailer(document.trailer, dict)
+				}
+			} else {
+				lexer.position = savedPosition
+			}
+		}
+
+		// Later definitions come from incremental updates, so they replace the earlier ones
+		document.objects[number] = object
+	}
+
+	for _, stream := range objectStreams {
+		document.readObjectStream(stream)
+	}
+
+	var trailerIndex = bytes.LastIndex(data, []byte("trailer"))
+	if trailerIndex >= 0 {
+		var lexer = &pdfLexer{data: data, position: trailerIndex + len("trailer")}
+		var trailer, ok = lexer.nextObject()
+		if lexer.err != nil {
+			return nil, lexer.err
+		}
+		if trailerDict, isDict := trailer.(pdfDict); ok && isDict {
+			mergeTrailer(document.trailer, trailerDict)
+		}
+	}
+
+	if _, encrypted := document.trailer["Encrypt"]; encrypted {
+		return nil, errors.New("Encrypted pdf documents are not supported")
+	}
+
+	return document, nil
+}
+
+func mergeTrailer(trailer pdfDict, dict pdfDict) {
+	for _, key := range []pdfName{"Root", "Info", "Encrypt"} {
+		if value, exists := dict[key]; exists {
+			trailer[key] = value
+		}
+	}
+}
+
+// readStreamData returns the raw data of a stream, which starts after the "stream" keyword,
+// and the position of its end in the file
+func readStreamData(data []byte, position int, dict pdfDict) ([]byte, int) {
+	// The keyword is followed by CRLF or LF before the data
+	if position < len(data) && data[position] == '\r' {
+		position++
+	}
+	if position < len(data) && data[position] == '\n' {
+		position++
+	}
+
+	// The length is used only when it's direct and points exactly to the end of the stream
+	if length, isNumber := dict["Length"].(float64); isNumber {
+		var end = position + int(length)
+		if end <= len(data) && end >= position {
+			var rest = bytes.TrimLeft(data[end:], " \r\n")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				return data[position:end], end
+			}
+		}
+	}
+
+	var end = bytes.Index(data[position:], []byte("endstream"))
+	if end < 0 {
+		return data[position:], len(data)
+	}
+
+	return bytes.TrimRight(data[position:position+end], "\r\n"), position + end
+}
+
+// readObjectStream adds the objects compressed in the object stream, unless they are defined directly
+func (d *pdfDocument) readObjectStream(stream *pdfStream) {
+	var decoded, err = d.decodeStream(stream)
+	if err != nil {
+		return
+	}
+
+	var count, _ = stream.dict["N"].(float64)
+	var first, _ = stream.dict["First"].(float64)
+	var header = &pdfLexer{data: decoded}
+
+	for i := 0; i < int(count); i++ {
+		var number, _ = header.nextToken()
+		var offset, _ = header.nextToken()
+		var objectNumber, isNumber = number.(float64)
+		var objectOffset, isOffset = offset.(float64)
+		if !isNumber || !isOffset {
+			return
+		}
+
+		if _, exists := d.objects[int(objectNumber)]; exists {
+			continue
+		}
+
+		var position = int(first) + int(objectOffset)
+		if position < 0 || position >= len(decoded) {
+			continue
+		}
+
+		var lexer = &pdfLexer{data: decoded, position: position}
+		if object, ok := lexer.nextObject(); ok {
+			d.objects[int(objectNumber)] = object
+		}
+	}
+}
+
+// resolve follows indirect references to the actual object
+func (d *pdfDocument) resolve(object interface{}) interface{} {
+	for depth := 0; depth < 32; depth++ {
+		var ref, isRef = object.(pdfRef)
+		if !isRef {
+			return object
+		}
+		object = d.objects[ref.number]
+	}
+
+	return nil
+}
+
+func (d *pdfDocument) resolveDict(object interface{}) pdfDict {
+	switch resolved := d.resolve(object).(type) {
+	case pdfDict:
+		return resolved
+	case *pdfStream:
+		return resolved.dict
+	}
+
+	return nil
+}
+
+func (d *pdfDocument) resolveArray(object interface{}) pdfArray {
+	var array, _ = d.resolve(object).(pdfArray)
+	return array
+}
+
+func (d *pdfDocument) resolveNumber(object interface{}, defaultValue float64) float64 {
+	if number, isNumber := d.resolve(object).(float64); isNumber {
+		return number
+	}
+
+	return defaultValue
+}
+
+// decodeStream applies the stream filters to its data
+func (d *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	var filters = []interface{}{}
+	switch filter := d.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = append(filters, filter)
+	case pdfArray:
+		filters = filter
+	}
+
+	var data = stream.data
+	for _, filter := range filters {
+		var err error
+		switch d.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflatePDFData(data)
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data = (&pdfLexer{data: append(append([]byte("<"), data...), '>')}).readHexString()
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			err = errors.New("Unsupported pdf stream filter")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// inflatePDFData decompresses flate streams, failing with BodyTooLargeError if they are larger than MaxBodySize
+func inflatePDFData(data []byte) ([]byte, error) {
+	var reader, err = zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// Some generators write raw deflate data without the zlib header
+		return readInflatedData(flate.NewReader(bytes.NewReader(data)))
+	}
+	defer reader.Close()
+
+	var decoded, readErr = readInflatedData(reader)
+	// Truncated streams are common, so the data decoded before the error is still used
+	if _, tooLarge := readErr.(*BodyTooLargeError); tooLarge || (readErr != nil && len(decoded) == 0) {
+		return nil, readErr
+	}
+
+	return decoded, nil
+}
+
+// readInflatedData reads the decompressed data up to MaxBodySize, so small streams can't expand to gigabytes
+func readInflatedData(reader io.Reader) ([]byte, error) {
+	if MaxBodySize <= 0 {
+		return ioutil.ReadAll(reader)
+	}
+
+	// Read one byte more than allowed so we can tell an exact fit from an overflow
+	var decoded, err = ioutil.ReadAll(io.LimitReader(reader, MaxBodySize+1))
+	if int64(len(decoded)) > MaxBodySize {
+		return nil, &BodyTooLargeError{Limit: MaxBodySize}
+	}
+
+	return decoded, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	var cleaned = []byte{}
+	for _, char := range data {
+		if !isPDFWhitespace(char) {
+			cleaned = append(cleaned, char)
+		}
+	}
+	cleaned = bytes.TrimPrefix(cleaned, []byte("<~"))
+	if end := bytes.Index(cleaned, []byte("~>")); end >= 0 {
+		cleaned = cleaned[:end]
+	}
+
+	// Every "z" stands for 4 zero bytes, so the decoded data can be longer than the input
+	var decoded = make([]byte, len(cleaned)*4+4)
+	var written, _, err = ascii85.Decode(decoded, cleaned, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded[:written], nil
+}
@@ -0,0 +1,820 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// pdfMatrix is an affine transformation matrix [a b c d e f]
+type pdfMatrix [6]float64
+
+var identityMatrix = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+func (m pdfMatrix) multiply(other pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*other[0] + m[1]*other[2],
+		m[0]*other[1] + m[1]*other[3],
+		m[2]*other[0] + m[3]*other[2],
+		m[2]*other[1] + m[3]*other[3],
+		m[4]*other[0] + m[5]*other[2] + other[4],
+		m[4]*other[1] + m[5]*other[3] + other[5],
+	}
+}
+
+func translateMatrix(x float64, y float64) pdfMatrix {
+	return pdfMatrix{1, 0, 0, 1, x, y}
+}
+
+// pdfFont decodes the character codes of a font to text and measures their widths
+type pdfFont struct {
+	twoByte      bool
+	toUnicode    map[int]string
+	encoding     [256]rune
+	widths       map[int]float64
+	defaultWidth float64
+}
+
+// pdfTextRun is a piece of text shown at one position of the page
+type pdfTextRun struct {
+	x    float64
+	y    float64
+	endX float64
+	size float64
+	text string
+}
+
+type pdfGraphicsState struct {
+	ctm          pdfMatrix
+	font         *pdfFont
+	fontSize     float64
+	charSpacing  float64
+	wordSpacing  float64
+	scaling      float64
+	leading      float64
+	rise         float64
+	textMatrix   pdfMatrix
+	lineMatrix   pdfMatrix
+	resources    pdfDict
+	fontsCache   map[pdfName]*pdfFont
+	formDepth    int
+	pageTextRuns *[]pdfTextRun
+}
+
+// ExtractTextFromPDF reads the title and the text of all pages of the pdf document.
+// Lines are joined into paragraphs by their positions and paragraphs are separated by "\n\n"
+func ExtractTextFromPDF(data []byte) (string, string, error) {
+	var document, err = parsePDF(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	var pages = document.pages()
+	if len(pages) == 0 {
+		return "", "", errors.New("The pdf document has no pages")
+	}
+
+	var pagesText = []string{}
+	for _, page := range pages {
+		var runs = document.extractPageTextRuns(page)
+		var paragraphs = buildPDFParagraphs(runs)
+		if len(paragraphs) > 0 {
+			pagesText = append(pagesText, strings.Join(paragraphs, "\n\n"))
+		}
+	}
+
+	if len(pagesText) == 0 {
+		// Scanned documents contain only images of the pages
+		return "", "", errors.New("The pdf document has no text")
+	}
+
+	var title = ""
+	if info := document.resolveDict(document.trailer["Info"]); info != nil {
+		title = strings.TrimSpace(decodePDFTextString(document.resolve(info["Title"])))
+	}
+
+	return title, joinPDFPages(pagesText), nil
+}
+
+// pages returns the pages in order, each with the resources inherited from its parents
+func (d *pdfDocument) pages() []pdfDict {
+	var catalog = d.resolveDict(d.trailer["Root"])
+	if catalog == nil {
+		// Without a trailer we search the catalog between the objects
+		for _, object := range d.objects {
+			if dict, isDict := object.(pdfDict); isDict && dict["Type"] == pdfName("Catalog") {
+				catalog = dict
+				break
+			}
+		}
+	}
+
+	if catalog == nil {
+		return nil
+	}
+
+	var pages = []pdfDict{}
+	d.collectPages(catalog["Pages"], nil, &pages, make(map[int]bool))
+	return pages
+}
+
+func (d *pdfDocument) collectPages(nodeObject interface{}, inheritedResources interface{}, pages *[]pdfDict, visited map[int]bool) {
+	if ref, isRef := nodeObject.(pdfRef); isRef {
+		if visited[ref.number] {
+			return
+		}
+		visited[ref.number] = true
+	}
+
+	var node = d.resolveDict(nodeObject)
+	if node == nil {
+		return
+	}
+
+	var resources = inheritedResources
+	if nodeResources, exists := node["Resources"]; exists {
+		resources = nodeResources
+	}
+
+	var kids, hasKids = d.resolve(node["Kids"]).(pdfArray)
+	if node["Type"] == pdfName("Pages") || hasKids {
+		for _, kid := range kids {
+			d.collectPages(kid, resources, pages, visited)
+		}
+		return
+	}
+
+	var page = pdfDict{}
+	for key, value := range node {
+		page[key] = value
+	}
+	page["Resources"] = resources
+	*pages = append(*pages, page)
+}
+
+// extractPageTextRuns runs the page content streams and collects the shown text
+func (d *pdfDocument) extractPageTextRuns(page pdfDict) []pdfTextRun {
+	var runs = []pdfTextRun{}
+	var content = []byte{}
+
+	var contents = d.resolve(page["Contents"])
+	var streams = pdfArray{contents}
+	if array, isArray := contents.(pdfArray); isArray {
+		streams = array
+	}
+
+	for _, streamObject := range streams {
+		if stream, isStream := d.resolve(streamObject).(*pdfStream); isStream {
+			if decoded, err := d.decodeStream(stream); err == nil {
+				content = append(content, decoded...)
+				content = append(content, '\n')
+			}
+		}
+	}
+
+	var state = &pdfGraphicsState{
+		ctm:          identityMatrix,
+		scaling:      1,
+		resources:    d.resolveDict(page["Resources"]),
+		fontsCache:   make(map[pdfName]*pdfFont),
+		pageTextRuns: &runs,
+	}
+	d.runContentStream(content, state)
+
+	return runs
+}
+
+// runContentStream interprets the text and graphics state operators of a content stream
+func (d *pdfDocument) runContentStream(content []byte, state *pdfGraphicsState) {
+	var lexer = &pdfLexer{data: content}
+	var operands = []interface{}{}
+	var savedStates = []pdfGraphicsState{}
+
+	for {
+		var object, ok = lexer.nextObject()
+		if !ok {
+			return
+		}
+
+		var operator, isOperator = object.(pdfKeyword)
+		if !isOperator {
+			operands = append(operands, object)
+			continue
+		}
+
+		switch operator {
+		case "q":
+			savedStates = append(savedStates, *state)
+		case "Q":
+			if len(savedStates) > 0 {
+				*state = savedStates[len(savedStates)-1]
+				savedStates = savedStates[:len(savedStates)-1]
+			}
+		case "cm":
+			if matrix, ok := operandsMatrix(operands); ok {
+				state.ctm = matrix.multiply(state.ctm)
+			}
+		case "BT":
+			state.textMatrix = identityMatrix
+			state.lineMatrix = identityMatrix
+		case "Tf":
+			if len(operands) >= 2 {
+				var fontName, _ = operands[len(operands)-2].(pdfName)
+				state.font = d.getFont(state, fontName)
+				state.fontSize = operandNumber(operands, len(operands)-1)
+			}
+		case "Tc":
+			state.charSpacing = operandNumber(operands, len(operands)-1)
+		case "Tw":
+			state.wordSpacing = operandNumber(operands, len(operands)-1)
+		case "Tz":
+			state.scaling = operandNumber(operands, len(operands)-1) / 100
+		case "TL":
+			state.leading = operandNumber(operands, len(operands)-1)
+		case "Ts":
+			state.rise = operandNumber(operands, len(operands)-1)
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				var x = operandNumber(operands, len(operands)-2)
+				var y = operandNumber(operands, len(operands)-1)
+				if operator == "TD" {
+					state.leading = -y
+				}
+				state.moveTextLine(x, y)
+			}
+		case "Tm":
+			if matrix, ok := operandsMatrix(operands); ok {
+				state.textMatrix = matrix
+				state.lineMatrix = matrix
+			}
+		case "T*":
+			state.moveTextLine(0, -state.leading)
+		case "Tj":
+			if len(operands) > 0 {
+				d.showText(state, operands[len(operands)-1])
+			}
+		case "'":
+			state.moveTextLine(0, -state.leading)
+			if len(operands) > 0 {
+				d.showText(state, operands[len(operands)-1])
+			}
+		case "\"":
+			if len(operands) >= 3 {
+				state.wordSpacing = operandNumber(operands, len(operands)-3)
+				state.charSpacing = operandNumber(operands, len(operands)-2)
+				state.moveTextLine(0, -state.leading)
+				d.showText(state, operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				var elements, _ = operands[len(operands)-1].(pdfArray)
+				for _, element := range elements {
+					if adjustment, isNumber := element.(float64); isNumber {
+						var shift = -adjustment / 1000 * state.fontSize * state.scaling
+						state.textMatrix = translateMatrix(shift, 0).multiply(state.textMatrix)
+					} else {
+						d.showText(state, element)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) > 0 {
+				var name, _ = operands[len(operands)-1].(pdfName)
+				d.runFormXObject(state, name)
+			}
+		case "BI":
+			skipInlineImage(lexer)
+		}
+
+		operands = operands[:0]
+	}
+}
+
+func (s *pdfGraphicsState) moveTextLine(x float64, y float64) {
+	s.lineMatrix = translateMatrix(x, y).multiply(s.lineMatrix)
+	s.textMatrix = s.lineMatrix
+}
+
+// showText decodes the string with the current font, adds it as a text run and advances the text position
+func (d *pdfDocument) showText(state *pdfGraphicsState, operand interface{}) {
+	var data, isString = operand.(pdfString)
+	if !isString || state.font == nil {
+		return
+	}
+
+	var renderingMatrix = pdfMatrix{state.fontSize * state.scaling, 0, 0, state.fontSize, 0, state.rise}
+	var start = renderingMatrix.multiply(state.textMatrix).multiply(state.ctm)
+	var deviceMatrix = state.textMatrix.multiply(state.ctm)
+	var size = state.fontSize * math.Hypot(deviceMatrix[2], deviceMatrix[3])
+
+	var text, codes = state.font.decode(data)
+	for _, code := range codes {
+		var advance = state.font.width(code)*state.fontSize + state.charSpacing
+		if code == 32 && !state.font.twoByte {
+			advance += state.wordSpacing
+		}
+		state.textMatrix = translateMatrix(advance*state.scaling, 0).multiply(state.textMatrix)
+	}
+
+	var end = renderingMatrix.multiply(state.textMatrix).multiply(state.ctm)
+	if text == "" {
+		return
+	}
+
+	*state.pageTextRuns = append(*state.pageTextRuns, pdfTextRun{x: start[4], y: start[5], endX: end[4], size: size, text: text})
+}
+
+// runFormXObject runs the content stream of a form XObject, which can contain text as well
+func (d *pdfDocument) runFormXObject(state *pdfGraphicsState, name pdfName) {
+	if state.formDepth > 8 {
+		return
+	}
+
+	var xObjects = d.resolveDict(state.resources["XObject"])
+	var form, isStream = d.resolve(xObjects[name]).(*pdfStream)
+	if !isStream || form.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+
+	var content, err = d.decodeStream(form)
+	if err != nil {
+		return
+	}
+
+	var formState = *state
+	formState.formDepth++
+	formState.fontsCache = make(map[pdfName]*pdfFont)
+	if formResources := d.resolveDict(form.dict["Resources"]); formResources != nil {
+		formState.resources = formResources
+	}
+	if matrix, ok := operandsMatrix(d.resolveArray(form.dict["Matrix"])); ok {
+		formState.ctm = matrix.multiply(state.ctm)
+	}
+
+	d.runContentStream(content, &formState)
+}
+
+// skipInlineImage moves the lexer after the binary data of an inline image
+func skipInlineImage(lexer *pdfLexer) {
+	var dataStart = bytes.Index(lexer.data[lexer.position:], []byte("ID"))
+	if dataStart < 0 {
+		lexer.position = len(lexer.data)
+		return
+	}
+
+	var position = lexer.position + dataStart + 2
+	for position+2 < len(lexer.data) {
+		if isPDFWhitespace(lexer.data[position]) && lexer.data[position+1] == 'E' && lexer.data[position+2] == 'I' &&
+			(position+3 == len(lexer.data) || isPDFWhitespace(lexer.data[position+3])) {
+			lexer.position = position + 3
+			return
+		}
+		position++
+	}
+
+	lexer.position = len(lexer.data)
+}
+
+func operandNumber(operands []interface{}, index int) float64 {
+	if index < 0 || index >= len(operands) {
+		return 0
+	}
+
+	var number, _ = operands[index].(float64)
+	return number
+}
+
+func operandsMatrix(operands []interface{}) (pdfMatrix, bool) {
+	if len(operands) < 6 {
+		return identityMatrix, false
+	}
+
+	var matrix pdfMatrix
+	var first = len(operands) - 6
+	for i := 0; i < 6; i++ {
+		var number, isNumber = operands[first+i].(float64)
+		if !isNumber {
+			return identityMatrix, false
+		}
+		matrix[i] = number
+	}
+
+	return matrix, true
+}
+
+// getFont loads the font from the current resources, caching it for the rest of the stream
+func (d *pdfDocument) getFont(state *pdfGraphicsState, name pdfName) *pdfFont {
+	if font, exists := state.fontsCache[name]; exists {
+		return font
+	}
+
+	var fonts = d.resolveDict(state.resources["Font"])
+	var font = d.loadFont(d.resolveDict(fonts[name]))
+	state.fontsCache[name] = font
+	return font
+}
+
+func (d *pdfDocument) loadFont(fontDict pdfDict) *pdfFont {
+	var font = &pdfFont{widths: make(map[int]float64), defaultWidth: 0.5}
+	if fontDict == nil {
+		font.encoding = standardPDFEncoding()
+		return font
+	}
+
+	font.twoByte = fontDict["Subtype"] == pdfName("Type0")
+	font.encoding = d.loadFontEncoding(fontDict)
+
+	if toUnicode, isStream := d.resolve(fontDict["ToUnicode"]).(*pdfStream); isStream {
+		if cmap, err := d.decodeStream(toUnicode); err == nil {
+			font.toUnicode = parseToUnicodeCMap(cmap)
+		}
+	}
+
+	if font.twoByte {
+		var descendants = d.resolveArray(fontDict["DescendantFonts"])
+		if len(descendants) > 0 {
+			var descendant = d.resolveDict(descendants[0])
+			font.defaultWidth = d.resolveNumber(descendant["DW"], 1000) / 1000
+			d.loadCIDWidths(font, d.resolveArray(descendant["W"]))
+		}
+		return font
+	}
+
+	var firstChar = int(d.resolveNumber(fontDict["FirstChar"], 0))
+	for i, width := range d.resolveArray(fontDict["Widths"]) {
+		font.widths[firstChar+i] = d.resolveNumber(width, 0) / 1000
+	}
+
+	if descriptor := d.resolveDict(fontDict["FontDescriptor"]); descriptor != nil {
+		if missingWidth := d.resolveNumber(descriptor["MissingWidth"], 0); missingWidth > 0 {
+			font.defaultWidth = missingWidth / 1000
+		}
+	}
+
+	return font
+}
+
+// loadCIDWidths reads the W array of a CID font, which contains
+// "first [w1 w2 ...]" and "first last w" entries
+func (d *pdfDocument) loadCIDWidths(font *pdfFont, widths pdfArray) {
+	for i := 0; i < len(widths); {
+		var first = int(d.resolveNumber(widths[i], 0))
+		if i+1 < len(widths) {
+			if array, isArray := d.resolve(widths[i+1]).(pdfArray); isArray {
+				for j, width := range array {
+					font.widths[first+j] = d.resolveNumber(width, 0) / 1000
+				}
+				i += 2
+				continue
+			}
+		}
+
+		if i+2 < len(widths) {
+			var last = int(d.resolveNumber(widths[i+1], 0))
+			var width = d.resolveNumber(widths[i+2], 0) / 1000
+			for code := first; code <= last && code-first < 65536; code++ {
+				font.widths[code] = width
+			}
+		}
+		i += 3
+	}
+}
+
+func (d *pdfDocument) loadFontEncoding(fontDict pdfDict) [256]rune {
+	var encoding = standardPDFEncoding()
+
+	var encodingObject = d.resolve(fontDict["Encoding"])
+	var baseEncoding = encodingObject
+	var differences pdfArray
+	if encodingDict, isDict := encodingObject.(pdfDict); isDict {
+		baseEncoding = d.resolve(encodingDict["BaseEncoding"])
+		differences = d.resolveArray(encodingDict["Differences"])
+	}
+
+	switch baseEncoding {
+	case pdfName("WinAnsiEncoding"):
+		encoding = winAnsiPDFEncoding()
+	case pdfName("MacRomanEncoding"):
+		encoding = macRomanPDFEncoding()
+	}
+
+	var code = 0
+	for _, difference := range differences {
+		switch value := d.resolve(difference).(type) {
+		case float64:
+			code = int(value)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				encoding[code] = glyphNameToRune(string(value))
+			}
+			code++
+		}
+	}
+
+	return encoding
+}
+
+// decode converts the string bytes to text, returning the character codes as well
+func (f *pdfFont) decode(data []byte) (string, []int) {
+	var text strings.Builder
+	var codes = []int{}
+
+	var codeLength = 1
+	if f.twoByte {
+		codeLength = 2
+	}
+
+	for i := 0; i+codeLength <= len(data); i += codeLength {
+		var code = int(data[i])
+		if codeLength == 2 {
+			code = int(data[i])<<8 | int(data[i+1])
+		}
+		codes = append(codes, code)
+
+		if unicodeText, exists := f.toUnicode[code]; exists {
+			text.WriteString(unicodeText)
+		} else if !f.twoByte && f.encoding[code] != 0 {
+			text.WriteRune(f.encoding[code])
+		}
+	}
+
+	return text.String(), codes
+}
+
+func (f *pdfFont) width(code int) float64 {
+	if width, exists := f.widths[code]; exists && width > 0 {
+		return width
+	}
+
+	return f.defaultWidth
+}
+
+// parseToUnicodeCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseToUnicodeCMap(cmap []byte) map[int]string {
+	var mapping = make(map[int]string)
+	var lexer = &pdfLexer{data: cmap}
+	var operands = []interface{}{}
+	var section = ""
+
+	for {
+		var object, ok = lexer.nextObject()
+		if !ok {
+			return mapping
+		}
+
+		var keyword, isKeyword = object.(pdfKeyword)
+		if !isKeyword {
+			operands = append(operands, object)
+			continue
+		}
+
+		switch keyword {
+		case "beginbfchar", "beginbfrange":
+			section = string(keyword)
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				var source, isSource = operands[i].(pdfString)
+				var destination, isDestination = operands[i+1].(pdfString)
+				if isSource && isDestination {
+					mapping[bytesToCode(source)] = decodeUTF16(destination)
+				}
+			}
+			section = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				addBFRange(mapping, operands[i], operands[i+1], operands[i+2])
+			}
+			section = ""
+		}
+
+		if section == "" || keyword == "beginbfchar" || keyword == "beginbfrange" {
+			operands = operands[:0]
+		}
+	}
+}
+
+func addBFRange(mapping map[int]string, lowObject interface{}, highObject interface{}, destinationObject interface{}) {
+	var low, isLow = lowObject.(pdfString)
+	var high, isHigh = highObject.(pdfString)
+	if !isLow || !isHigh {
+		return
+	}
+
+	var lowCode = bytesToCode(low)
+	var highCode = bytesToCode(high)
+	if highCode < lowCode || highCode-lowCode > 65535 {
+		return
+	}
+
+	switch destination := destinationObject.(type) {
+	case pdfString:
+		// The last character of the destination is incremented for every code in the range
+		var runes = []rune(decodeUTF16(destination))
+		if len(runes) == 0 {
+			return
+		}
+		for code := lowCode; code <= highCode; code++ {
+			var current = append([]rune{}, runes...)
+			current[len(current)-1] += rune(code - lowCode)
+			mapping[code] = string(current)
+		}
+	case pdfArray:
+		for i, element := range destination {
+			if text, isString := element.(pdfString); isString && lowCode+i <= highCode {
+				mapping[lowCode+i] = decodeUTF16(text)
+			}
+		}
+	}
+}
+
+func bytesToCode(data []byte) int {
+	var code = 0
+	for _, char := range data {
+		code = code<<8 | int(char)
+	}
+	return code
+}
+
+func decodeUTF16(data []byte) string {
+	var units = []uint16{}
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodePDFTextString decodes strings outside of content streams, e.g. the document title,
+// which are either UTF-16BE with a byte order mark or PDFDocEncoding
+func decodePDFTextString(object interface{}) string {
+	var data, isString = object.(pdfString)
+	if !isString {
+		return ""
+	}
+
+	if len(data) >= 2 && data[0] == 0xfe && data[1] == 0xff {
+		return decodeUTF16(data[2:])
+	}
+
+	if len(data) >= 3 && data[0] == 0xef && data[1] == 0xbb && data[2] == 0xbf {
+		return string(data[3:])
+	}
+
+	// PDFDocEncoding matches Latin-1 for the printable characters
+	var runes = make([]rune, len(data))
+	for i, char := range data {
+		runes[i] = rune(char)
+	}
+	return string(runes)
+}
+
+// buildPDFParagraphs joins the text runs into lines and the lines into paragraphs.
+// A new line starts when the vertical position changes and a new paragraph starts after
+// a gap bigger than the usual line spacing, after moving up, e.g. to a new column, or on a font size change
+func buildPDFParagraphs(runs []pdfTextRun) []string {
+	var lines = buildPDFLines(runs)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var lineSpacing = typicalLineSpacing(lines)
+	var paragraphs = []string{}
+	var currentParagraph = ""
+
+	for i, line := range lines {
+		var text = strings.TrimSpace(line.text)
+
+		// Lines with only a number are most likely page numbers
+		if _, err := strconv.Atoi(text); err == nil && (i == 0 || i == len(lines)-1) {
+			continue
+		}
+
+		if i > 0 && currentParagraph != "" {
+			var previous = lines[i-1]
+			var gap = previous.y - line.y
+			var sizeChanged = math.Abs(previous.size-line.size) > 0.15*math.Max(previous.size, line.size)
+			if gap < 0 || gap > lineSpacing*1.4 || sizeChanged {
+				paragraphs = append(paragraphs, currentParagraph)
+				currentParagraph = ""
+			}
+		}
+
+		currentParagraph = joinPDFLines(currentParagraph, text)
+	}
+
+	if currentParagraph != "" {
+		paragraphs = append(paragraphs, currentParagraph)
+	}
+
+	return paragraphs
+}
+
+// buildPDFLines groups consecutive runs with the same vertical position into lines,
+// adding spaces where the gap between the runs is wide enough
+func buildPDFLines(runs []pdfTextRun) []pdfTextRun {
+	var lines = []pdfTextRun{}
+
+	for _, run := range runs {
+		if strings.TrimSpace(run.text) == "" && len(lines) == 0 {
+			continue
+		}
+
+		if len(lines) > 0 {
+			var line = &lines[len(lines)-1]
+			var tolerance = math.Max(line.size, run.size) * 0.5
+			if math.Abs(line.y-run.y) <= tolerance && run.x >= line.x-tolerance {
+				var gap = run.x - line.endX
+				var needsSpace = gap > line.size*0.15 &&
+					!strings.HasSuffix(line.text, " ") && !strings.HasPrefix(run.text, " ")
+				if needsSpace {
+					line.text += " "
+				}
+				line.text += run.text
+				line.endX = math.Max(line.endX, run.endX)
+				line.size = math.Max(line.size, run.size)
+				continue
+			}
+		}
+
+		lines = append(lines, run)
+	}
+
+	var nonEmptyLines = []pdfTextRun{}
+	for _, line := range lines {
+		if strings.TrimSpace(line.text) != "" {
+			nonEmptyLines = append(nonEmptyLines, line)
+		}
+	}
+
+	return nonEmptyLines
+}
+
+// typicalLineSpacing returns the most common distance between two consecutive lines
+func typicalLineSpacing(lines []pdfTextRun) float64 {
+	var counts = make(map[int]int)
+	var bestSpacing = 0
+	for i := 1; i < len(lines); i++ {
+		var spacing = int(math.Round(lines[i-1].y - lines[i].y))
+		if spacing <= 0 {
+			continue
+		}
+
+		counts[spacing]++
+		if counts[spacing] > counts[bestSpacing] || (counts[spacing] == counts[bestSpacing] && spacing < bestSpacing) {
+			bestSpacing = spacing
+		}
+	}
+
+	if bestSpacing == 0 {
+		return lines[0].size * 1.2
+	}
+
+	return float64(bestSpacing)
+}
+
+// joinPDFLines appends the line to the paragraph, joining words hyphenated at the end of the line
+func joinPDFLines(paragraph string, line string) string {
+	if paragraph == "" {
+		return line
+	}
+
+	var runes = []rune(paragraph)
+	var lineRunes = []rune(line)
+	if len(runes) > 1 && len(lineRunes) > 0 && runes[len(runes)-1] == '-' &&
+		unicode.IsLetter(runes[len(runes)-2]) && unicode.IsLower(lineRunes[0]) {
+		return string(runes[:len(runes)-1]) + line
+	}
+
+	return paragraph + " " + line
+}
+
+// joinPDFPages joins the text of the pages, continuing a paragraph broken by the page end
+func joinPDFPages(pagesText []string) string {
+	var result = ""
+	for _, pageText := range pagesText {
+		if result == "" {
+			result = pageText
+			continue
+		}
+
+		var lastRune, _ = lastRuneOf(result)
+		if strings.ContainsRune(".!?:\"”»", lastRune) {
+			result += "\n\n" + pageText
+		} else {
+			result = joinPDFLines(result, pageText)
+		}
+	}
+
+	return result
+}
+
+func lastRuneOf(text string) (rune, bool) {
+	var runes = []rune(text)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	return runes[len(runes)-1], true
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildTestPDF creates a minimal single page pdf document with the given content stream
+func buildTestPDF(title string, content string, compress bool) []byte {
+	var streamData = []byte(content)
+	var filter = ""
+	if compress {
+		var buffer bytes.Buffer
+		var writer = zlib.NewWriter(&buffer)
+		writer.Write(streamData)
+		writer.Close()
+		streamData = buffer.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 5 0 R >> >> >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(streamData), filter)
+	pdf.Write(streamData)
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+	fmt.Fprintf(&pdf, "6 0 obj\n<< /Title (%s) >>\nendobj\n", title)
+	pdf.WriteString("trailer\n<< /Root 1 0 R /Info 6 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestExtractingTextFromPDF(t *testing.T) {
+	var content = `BT /F1 12 Tf 14 TL 72 760 Td
+		(The first paragraph is written) Tj T* (on two lines.) Tj
+		0 -40 Td [(The second paragraph has a hyphen-) -250] TJ T* (ated word.) Tj
+		ET
+		BT /F1 10 Tf 290 40 Td (7) Tj ET`
+
+	var title, text, err = ExtractTextFromPDF(buildTestPDF("Test \\(pdf\\) title", content, true))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if title != "Test (pdf) title" {
+		t.Error("Expected document title but received: ", title)
+	}
+
+	var expectedText = "The first paragraph is written on two lines.\n\nThe second paragraph has a hyphenated word."
+	if text != expectedText {
+		t.Error("Expected two paragraphs without the page number but received: ", text)
+	}
+}
+
+func TestExtractingTextFromPDFWithKerning(t *testing.T) {
+	var content = `BT /F1 12 Tf 1 0 0 1 72 760 Tm [(Ke) 30 (rned) -3000 (words)] TJ ET`
+
+	var _, text, err = ExtractTextFromPDF(buildTestPDF("", content, false))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if text != "Kerned words" {
+		t.Error("Expected spaces only between the words but received: ", text)
+	}
+}
+
+func TestExtractingTextFromInvalidPDF(t *testing.T) {
+	var _, _, err = ExtractTextFromPDF([]byte("%PDF-1.4\nnot really a pdf"))
+	if err == nil {
+		t.Error("Expected error for pdf without pages")
+	}
+}
+
+func TestExtractingTextFromRenderedPDF(t *testing.T) {
+	var text = "Умни машини обобщават текстове. The summary is stored as pdf."
+	var content bytes.Buffer
+	var err = RenderSummary(context.Background(), &content, "application/pdf", &Summary{Title: "Title", Text: text}, nil)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var data = content.Bytes()
+
+	if format := DetectFormat("summary", data); format != FormatPDF {
+		t.Error("Expected pdf format but received: ", format)
+	}
+
+	document, err := ReadDocument(data, FormatPDF, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if !strings.Contains(document.Text, text) {
+		t.Error("Expected the stored text but received: ", document.Text)
+	}
+}
+
+func TestInflatingTooLargePDFStream(t *testing.T) {
+	var originalMaxBodySize = MaxBodySize
+	MaxBodySize = 1024
+	defer func() { MaxBodySize = originalMaxBodySize }()
+
+	var buffer bytes.Buffer
+	var writer = zlib.NewWriter(&buffer)
+	writer.Write(bytes.Repeat([]byte("0"), 2048))
+	writer.Close()
+
+	var _, err = inflatePDFData(buffer.Bytes())
+	if _, tooLarge := err.(*BodyTooLargeError); !tooLarge {
+		t.Error("Expected BodyTooLargeError but received: ", err)
+	}
+
+	buffer.Reset()
+	writer = zlib.NewWriter(&buffer)
+	writer.Write([]byte(strings.Repeat("Text fits. ", 50)))
+	writer.Close()
+
+	decoded, err := inflatePDFData(buffer.Bytes()[:buffer.Len()-8])
+	if err != nil || len(decoded) == 0 {
+		t.Error("Expected the data of a truncated stream but received: ", len(decoded), err)
+	}
+}
+
+func TestExtractingTextFromDeeplyNestedPDF(t *testing.T) {
+	var content = "%PDF-1.4\n1 0 obj\n" + strings.Repeat("[", 100000) + "\nendobj\n"
+	var _, _, err = ExtractTextFromPDF([]byte(content))
+	if err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Error("Expected error for too deeply nested objects but received: ", err)
+	}
+
+	// Nested arrays in content streams are not read further, but the text before them is kept
+	_, text, err := ExtractTextFromPDF(buildTestPDF("", "BT /F1 12 Tf 72 760 Td (Nested) Tj ET "+strings.Repeat("[", 1000), false))
+	if err != nil || text != "Nested" {
+		t.Error("Expected the text before the nested arrays but received: ", text, err)
+	}
+}
@@ -0,0 +1,554 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"goSummarizer/fonts"
+
+	"github.com/signintech/gopdf"
+)
+
+// PageSize is the size of the pdf pages in points, in portrait orientation
+type PageSize struct {
+	Width  float64
+	Height float64
+}
+
+// Common page sizes
+var (
+	PageSizeA4     = PageSize{Width: 595.28, Height: 841.89}
+	PageSizeA5     = PageSize{Width: 419.53, Height: 595.28}
+	PageSizeLetter = PageSize{Width: 612, Height: 792}
+	PageSizeLegal  = PageSize{Width: 612, Height: 1008}
+)
+
+// PDFStyle configures the layout of the pdf files. Start from DefaultPDFStyle and change what's needed.
+// The fonts are TrueType fonts given as data or file path. The embedded Open Sans is used for the body,
+// if no font is given. If no title font is given, the embedded Open Sans Bold is used for the title
+// with the default body font and the body font with a custom one
+type PDFStyle struct {
+	PageSize     PageSize
+	Landscape    bool
+	MarginTop    float64
+	MarginRight  float64
+	MarginBottom float64
+	MarginLeft   float64
+
+	FontPath      string
+	FontData      []byte
+	TitleFontPath string
+	TitleFontData []byte
+	FontSize      float64
+	TitleFontSize float64
+	// LineSpacing is the height of the lines as multiple of the font size
+	LineSpacing float64
+	// ParagraphSpacing is the space after the paragraphs as multiple of the font size
+	ParagraphSpacing float64
+	Justify          bool
+	LineBreaking     LineBreaking
+	// MinParagraphLines is the number of lines of a paragraph kept together at the bottom
+	// and at the top of a page, which prevents widows and orphans. 0 and 1 disable it
+	MinParagraphLines int
+
+	// MaxImages is the maximum number of images written under the title. 0 writes no images
+	MaxImages int
+	// ImageCaptions writes the number and the source of every image under it
+	ImageCaptions bool
+	// ImageDir is a directory with the images of the summary. Images found there by their file name
+	// are not downloaded, so the pdf files can be written offline
+	ImageDir string
+	// AllowLocalImages reads images given as local paths or file urls from anywhere on the disk.
+	// Without it they are only looked up by file name in ImageDir, as the images usually come from scraped pages
+	AllowLocalImages bool
+
+	PageNumbers  bool
+	SourceFooter bool
+	Timestamp    bool
+	// GeneratedAt is the time written with Timestamp. The current time is used if it's zero
+	GeneratedAt time.Time
+}
+
+// DefaultPDFStyle returns the style of the pdf files, which are stored without a custom style
+func DefaultPDFStyle() PDFStyle {
+	return PDFStyle{
+		PageSize:          PageSizeA4,
+		MarginTop:         50,
+		MarginRight:       50,
+		MarginBottom:      50,
+		MarginLeft:        50,
+		FontSize:          12,
+		TitleFontSize:     18,
+		LineSpacing:       1.4,
+		ParagraphSpacing:  0.5,
+		MinParagraphLines: 2,
+		MaxImages:         2,
+		ImageCaptions:     true,
+		PageNumbers:       true,
+		SourceFooter:      true,
+	}
+}
+
+// Font families of the pdf writer, the sizes of the footer, the header and the links,
+// the size of the chapter headings as multiple of the font size and the words of the sentences in the context links
+const (
+	pdfBodyFont      = "body"
+	pdfTitleFont     = "title"
+	pdfFooterSize    = 8
+	pdfLinkSize      = 8
+	pdfChapterScale  = 1.25
+	pdfContextWords  = 4
+	pdfTimestampForm = "2006-01-02 15:04"
+	pdfCreator       = "go-summarizer"
+)
+
+// pdfWriter writes the summary to the pages, keeping the position on the current page
+type pdfWriter struct {
+	pdf    *gopdf.GoPdf
+	style  PDFStyle
+	width  float64
+	height float64
+	y      float64
+	page   int
+	source string
+	// infoEntries are written to the document information in addition to the ones of gopdf
+	infoEntries string
+}
+
+// newPDFWriter starts the pdf document and loads the fonts of the style
+func newPDFWriter(style PDFStyle, source string) (*pdfWriter, error) {
+	var defaultStyle = DefaultPDFStyle()
+	if style.PageSize.Width <= 0 || style.PageSize.Height <= 0 {
+		style.PageSize = defaultStyle.PageSize
+	}
+	if style.FontSize <= 0 {
+		style.FontSize = defaultStyle.FontSize
+	}
+	if style.TitleFontSize <= 0 {
+		style.TitleFontSize = defaultStyle.TitleFontSize
+	}
+	if style.LineSpacing <= 0 {
+		style.LineSpacing = defaultStyle.LineSpacing
+	}
+	if style.GeneratedAt.IsZero() {
+		style.GeneratedAt = time.Now()
+	}
+
+	var writer = &pdfWriter{pdf: &gopdf.GoPdf{}, style: style, width: style.PageSize.Width, height: style.PageSize.Height, source: source}
+	if style.Landscape {
+		writer.width, writer.height = writer.height, writer.width
+	}
+	writer.pdf.Start(gopdf.Config{PageSize: gopdf.Rect{W: writer.width, H: writer.height}})
+
+	var err = addPDFFont(writer.pdf, pdfBodyFont, style.FontData, style.FontPath, fonts.OpenSansRegular)
+	if err != nil {
+		return nil, err
+	}
+
+	var titleFontData, titleFontPath = style.TitleFontData, style.TitleFontPath
+	if len(titleFontData) == 0 && titleFontPath == "" {
+		titleFontData, titleFontPath = style.FontData, style.FontPath
+	}
+	err = addPDFFont(writer.pdf, pdfTitleFont, titleFontData, titleFontPath, fonts.OpenSansBold)
+	if err != nil {
+		return nil, err
+	}
+
+	writer.pdf.AddHeader(writer.writeHeader)
+	writer.pdf.AddFooter(writer.writeFooter)
+	return writer, nil
+}
+
+func addPDFFont(pdf *gopdf.GoPdf, family string, data []byte, path string, defaultData []byte) error {
+	if len(data) > 0 {
+		return pdf.AddTTFFontData(family, data)
+	}
+	if path != "" {
+		return pdf.AddTTFFont(family, path)
+	}
+	return pdf.AddTTFFontData(family, defaultData)
+}
+
+// addPage starts a new page at its top margin. The header and the footer are written by gopdf
+func (w *pdfWriter) addPage() {
+	w.page++
+	w.pdf.AddPage()
+	w.y = w.style.MarginTop
+}
+
+// contentWidth is the width between the margins
+func (w *pdfWriter) contentWidth() float64 {
+	return w.width - w.style.MarginLeft - w.style.MarginRight
+}
+
+// contentBottom is the lowest position for content on the page
+func (w *pdfWriter) contentBottom() float64 {
+	return w.height - w.style.MarginBottom
+}
+
+// ensureSpace starts a new page if the height doesn't fit on the current one
+func (w *pdfWriter) ensureSpace(height float64) {
+	if w.y+height > w.contentBottom() && w.y > w.style.MarginTop {
+		w.addPage()
+	}
+}
+
+func (w *pdfWriter) writeHeader() {
+	if !w.style.Timestamp {
+		return
+	}
+
+	var text = "Generated " + w.style.GeneratedAt.Format(pdfTimestampForm)
+	if w.pdf.SetFont(pdfBodyFont, "", pdfFooterSize) != nil {
+		return
+	}
+	var textWidth, _ = w.pdf.MeasureTextWidth(text)
+	w.pdf.SetXY(w.width-w.style.MarginRight-textWidth, w.style.MarginTop/2-pdfFooterSize/2)
+	w.pdf.Cell(nil, text)
+}
+
+func (w *pdfWriter) writeFooter() {
+	if w.pdf.SetFont(pdfBodyFont, "", pdfFooterSize) != nil {
+		return
+	}
+
+	var y = w.height - w.style.MarginBottom/2 - pdfFooterSize/2
+	var available = w.contentWidth()
+	if w.style.PageNumbers {
+		var pageText = "Page " + strconv.Itoa(w.page)
+		var pageWidth, _ = w.pdf.MeasureTextWidth(pageText)
+		w.pdf.SetXY(w.width-w.style.MarginRight-pageWidth, y)
+		w.pdf.Cell(nil, pageText)
+		available -= pageWidth + pdfFooterSize
+	}
+
+	if w.style.SourceFooter && w.source != "" {
+		var source = w.fitText(w.source, available)
+		var sourceWidth, _ = w.pdf.MeasureTextWidth(source)
+		w.pdf.SetXY(w.style.MarginLeft, y)
+		w.pdf.Cell(nil, source)
+		if isWebURL(w.source) {
+			w.pdf.AddExternalLink(w.source, w.style.MarginLeft, y, sourceWidth, pdfFooterSize)
+		}
+	}
+}
+
+// fitText shortens the text with an ellipsis, so it fits in the width with the current font
+func (w *pdfWriter) fitText(text string, width float64) string {
+	var textWidth, err = w.pdf.MeasureTextWidth(text)
+	if err != nil || textWidth <= width {
+		return text
+	}
+
+	var runes = []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if textWidth, err = w.pdf.MeasureTextWidth(string(runes) + "…"); err == nil && textWidth <= width {
+			break
+		}
+	}
+	return string(runes) + "…"
+}
+
+// writeLine writes a line of a paragraph at the current position and moves below it.
+// Justified lines are stretched to the content width, except for the last line of the paragraph
+func (w *pdfWriter) writeLine(line layoutLine, justify bool) error {
+	var x = w.style.MarginLeft
+	if !justify || line.Last || len(line.Words) < 2 {
+		w.pdf.SetXY(x, w.y)
+		return w.pdf.Cell(nil, strings.Join(line.Words, " "))
+	}
+
+	var wordsWidth = 0.0
+	for _, width := range line.Widths {
+		wordsWidth += width
+	}
+	var gap = (w.contentWidth() - wordsWidth) / float64(len(line.Words)-1)
+
+	for i, word := range line.Words {
+		w.pdf.SetXY(x, w.y)
+		if err := w.pdf.Cell(nil, word); err != nil {
+			return err
+		}
+		x += line.Widths[i] + gap
+	}
+	return nil
+}
+
+// writeParagraphs writes every line of the text as a paragraph. Empty lines are skipped
+func (w *pdfWriter) writeParagraphs(family string, size float64, text string, justify bool) error {
+	for _, paragraph := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+
+		if err := w.writeParagraph(family, size, paragraph, justify); err != nil {
+			return err
+		}
+		w.y += size * w.style.ParagraphSpacing
+	}
+
+	return nil
+}
+
+// writeParagraph lays out the lines of the paragraph in the content width and breaks the pages,
+// keeping at least MinParagraphLines lines of the paragraph together
+func (w *pdfWriter) writeParagraph(family string, size float64, paragraph string, justify bool) error {
+	var lineHeight = size * w.style.LineSpacing
+	var measure = func(text string) (float64, error) {
+		if err := w.pdf.SetFont(family, "", size); err != nil {
+			return 0, err
+		}
+		return w.pdf.MeasureTextWidth(text)
+	}
+
+	var lines, err = layoutParagraph(paragraph, w.contentWidth(), measure, w.style.LineBreaking)
+	if err != nil {
+		return err
+	}
+
+	for len(lines) > 0 {
+		var available = int((w.contentBottom() - w.y + 0.001) / lineHeight)
+		var count = linesOnPage(len(lines), available, w.style.MinParagraphLines, w.y <= w.style.MarginTop)
+		if count == 0 {
+			w.addPage()
+			continue
+		}
+
+		// The header and the footer of a new page change the font
+		if err := w.pdf.SetFont(family, "", size); err != nil {
+			return err
+		}
+		for _, line := range lines[:count] {
+			if err := w.writeLine(line, justify); err != nil {
+				return err
+			}
+			w.y += lineHeight
+		}
+
+		lines = lines[count:]
+		if len(lines) > 0 {
+			w.addPage()
+		}
+	}
+
+	return nil
+}
+
+// writeLink writes a single line of small text, which opens the url
+func (w *pdfWriter) writeLink(text string, url string) error {
+	var lineHeight = pdfLinkSize * w.style.LineSpacing
+	w.ensureSpace(lineHeight)
+
+	var err = w.pdf.SetFont(pdfBodyFont, "", pdfLinkSize)
+	if err != nil {
+		return err
+	}
+
+	text = w.fitText(text, w.contentWidth())
+	var textWidth, _ = w.pdf.MeasureTextWidth(text)
+	w.pdf.SetXY(w.style.MarginLeft, w.y)
+	if err = w.pdf.Cell(nil, text); err != nil {
+		return err
+	}
+
+	w.pdf.AddExternalLink(url, w.style.MarginLeft, w.y, textWidth, lineHeight)
+	w.y += lineHeight
+	return nil
+}
+
+// writeHeading writes the heading of a section and adds a bookmark for it
+func (w *pdfWriter) writeHeading(family string, size float64, heading string) error {
+	// The bookmark must point to the page of the heading, so the page is broken before it
+	w.ensureSpace(size * w.style.LineSpacing * float64(maxInt(w.style.MinParagraphLines, 1)))
+	w.pdf.SetY(w.y)
+	w.pdf.AddOutlineWithPosition(heading)
+
+	if err := w.writeParagraph(family, size, heading, false); err != nil {
+		return err
+	}
+	w.y += size * w.style.ParagraphSpacing
+	return nil
+}
+
+// writeSentences writes the summary sentences as paragraphs. If the source is a web page,
+// every sentence found in the text has a link to it in the page
+func (w *pdfWriter) writeSentences(summary *Summary) error {
+	if summary.SourceURL == "" || len(summary.Sentences) == 0 || !isWebURL(summary.SourceURL) {
+		return w.writeParagraphs(pdfBodyFont, w.style.FontSize, summary.Text, w.style.Justify)
+	}
+
+	for _, sentence := range summary.Sentences {
+		if err := w.writeParagraph(pdfBodyFont, w.style.FontSize, sentence.Text, w.style.Justify); err != nil {
+			return err
+		}
+		if sentence.Offset >= 0 {
+			if err := w.writeLink("Read in context", getContextURL(summary.SourceURL, sentence.Text)); err != nil {
+				return err
+			}
+		}
+		w.y += w.style.FontSize * w.style.ParagraphSpacing
+	}
+
+	return nil
+}
+
+// getContextURL returns the url of the page with a text fragment, which highlights the sentence in the browsers supporting it
+func getContextURL(sourceURL string, sentence string) string {
+	var encode = func(words []string) string {
+		var encoded = strings.Replace(url.QueryEscape(strings.Join(words, " ")), "+", "%20", -1)
+		return strings.Replace(encoded, "-", "%2D", -1)
+	}
+
+	var fragment = ""
+	var words = strings.Fields(sentence)
+	if len(words) > 2*pdfContextWords {
+		fragment = encode(words[:pdfContextWords]) + "," + encode(words[len(words)-pdfContextWords:])
+	} else {
+		fragment = encode(words)
+	}
+
+	if index := strings.Index(sourceURL, "#"); index >= 0 {
+		sourceURL = sourceURL[:index]
+	}
+	return sourceURL + "#:~:text=" + fragment
+}
+
+// setInfo fills the document information of the pdf from the summary. The keywords
+// are added to the written document, because gopdf has no keywords
+func (w *pdfWriter) setInfo(summary *Summary) {
+	var subject = summary.Metadata[MetadataDescription]
+	if subject == "" {
+		subject = "Summary of " + summary.SourceURL
+		if summary.SourceURL == "" {
+			subject = "Summary"
+		}
+	}
+
+	w.pdf.SetInfo(gopdf.PdfInfo{
+		Title:        summary.Title,
+		Author:       summary.Metadata[MetadataAuthor],
+		Subject:      subject,
+		Creator:      pdfCreator,
+		Producer:     pdfCreator,
+		CreationDate: w.style.GeneratedAt.UTC(),
+	})
+
+	var keywords = strings.Join(summary.Keywords, ", ")
+	if keywords == "" {
+		keywords = summary.Metadata[MetadataKeywords]
+	}
+
+	if keywords != "" {
+		w.infoEntries = "/Keywords " + encodePDFString(keywords) + "\n"
+	}
+}
+
+// addInfoEntries adds the entries to the document information dictionary, which gopdf writes in the trailer.
+// The trailer is after the cross-reference table, so the object offsets don't change. An error is returned
+// if the dictionary isn't found, e.g. after gopdf changes how it writes it, so the entries aren't lost silently
+func (w *pdfWriter) addInfoEntries(content []byte) ([]byte, error) {
+	if w.infoEntries == "" {
+		return content, nil
+	}
+
+	var marker = []byte("/Info <<\n")
+	var index = bytes.LastIndex(content, marker)
+	if index < 0 {
+		return nil, errors.New("The document information of the pdf file was not found")
+	}
+
+	index += len(marker)
+	var result = append([]byte{}, content[:index]...)
+	result = append(result, w.infoEntries...)
+	return append(result, content[index:]...), nil
+}
+
+// encodePDFString encodes the text as UTF-16 hex string with byte order mark
+func encodePDFString(text string) string {
+	var encoded strings.Builder
+	encoded.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		encoded.WriteString(fmt.Sprintf("%04X", unit))
+	}
+	encoded.WriteString(">")
+	return encoded.String()
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// renderPDF writes the title, the images, the summary sentences and the chapter summaries with the style.
+// The title and the chapters have bookmarks
+func renderPDF(ctx context.Context, out io.Writer, summary *Summary, fetcher Fetcher, style PDFStyle) error {
+	var writer, err = newPDFWriter(style, summary.SourceURL)
+	if err != nil {
+		return err
+	}
+	writer.setInfo(summary)
+	writer.addPage()
+
+	if summary.Title != "" {
+		err = writer.writeHeading(pdfTitleFont, writer.style.TitleFontSize, summary.Title)
+		if err != nil {
+			return err
+		}
+	}
+
+	if isWebURL(summary.SourceURL) {
+		if err = writer.writeLink("Source: "+summary.SourceURL, summary.SourceURL); err != nil {
+			return err
+		}
+		writer.y += writer.style.FontSize * writer.style.ParagraphSpacing
+	}
+
+	err = writer.writeImages(ctx, summary.Images, fetcher)
+	if err != nil {
+		return err
+	}
+
+	err = writer.writeSentences(summary)
+	if err != nil {
+		return err
+	}
+
+	for _, chapter := range summary.Chapters {
+		if chapter.Summary == "" {
+			continue
+		}
+
+		err = writer.writeHeading(pdfTitleFont, writer.style.FontSize*pdfChapterScale, chapter.Title)
+		if err != nil {
+			return err
+		}
+		err = writer.writeParagraphs(pdfBodyFont, writer.style.FontSize, chapter.Summary, writer.style.Justify)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := writer.pdf.GetBytesPdfReturnErr()
+	if err != nil {
+		return err
+	}
+
+	content, err = writer.addInfoEntries(content)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(content)
+	return err
+}
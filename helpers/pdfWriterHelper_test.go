@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"goSummarizer/fonts"
+)
+
+func TestRenderingPDFWithStyle(t *testing.T) {
+	var style = DefaultPDFStyle()
+	style.PageSize = PageSizeLetter
+	style.Landscape = true
+	style.Timestamp = true
+	style.Justify = true
+	style.LineBreaking = LineBreakingOptimal
+	style.GeneratedAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	var summary = &Summary{
+		Title:     "Styled summary",
+		SourceURL: "http://test.test/styled",
+		Text:      strings.Repeat("A sentence, which fills the pages of the summary.\n\n\n", 60) + strings.Repeat("long", 80),
+	}
+
+	var content bytes.Buffer
+	var renderer = PDFRenderer{Context: context.Background(), Style: &style}
+	if err := renderer.Render(&content, summary); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var _, text, err = ExtractTextFromPDF(content.Bytes())
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	for _, expected := range []string{"Styled summary", "Generated 2024-03-01 09:30", "http://test.test/styled", "Page 1", "Page 2", "longlong"} {
+		if !strings.Contains(text, expected) {
+			t.Error("Expected pdf text to contain ", expected, " but received: ", text)
+		}
+	}
+
+	if !bytes.Contains(content.Bytes(), []byte("/MediaBox [ 0 0 792.00 612.00 ]")) {
+		t.Error("Expected landscape letter pages")
+	}
+}
+
+func TestRenderingPDFMetadataBookmarksAndLinks(t *testing.T) {
+	var style = DefaultPDFStyle()
+	style.GeneratedAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	var summary = &Summary{
+		Title:     "Book summary",
+		SourceURL: "http://test.test/book",
+		Metadata:  map[string]string{MetadataAuthor: "Иван Вазов"},
+		Sentences: []SummarySentence{
+			{Text: "The first chapter starts the story of a very long journey through the mountains.", Offset: 10},
+			{Text: "Not found in the text.", Offset: -1},
+		},
+		Keywords: []string{"journey", "mountains"},
+		Chapters: []Chapter{{Title: "Chapter one", Summary: "The journey starts."}, {Title: "Chapter two", Summary: "It ends."}},
+	}
+
+	var content bytes.Buffer
+	if err := (PDFRenderer{Style: &style}).Render(&content, summary); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedParts = []string{
+		"/Title " + encodePDFString("Book summary"),
+		"/Author " + encodePDFString("Иван Вазов"),
+		"/Keywords " + encodePDFString("journey, mountains"),
+		"/CreationDate(D:20240301093000+00'00')\n >>\n>>\nstartxref",
+		"/Title <FEFF" + encodePDFString("Chapter two")[5:],
+		"(http://test.test/book)",
+		"(http://test.test/book#:~:text=The%20first%20chapter%20starts,journey%20through%20the%20mountains.)",
+	}
+	for _, part := range expectedParts {
+		if !bytes.Contains(content.Bytes(), []byte(part)) {
+			t.Error("Expected pdf to contain ", part)
+		}
+	}
+
+	if count := bytes.Count(content.Bytes(), []byte("#:~:text=")); count != 1 {
+		t.Error("Expected one context link but received: ", count)
+	}
+
+	var _, text, err = ExtractTextFromPDF(content.Bytes())
+	if err != nil || !strings.Contains(text, "It ends.") {
+		t.Error("Expected readable pdf with the chapter summaries but received: ", text, err)
+	}
+}
+
+func TestRenderingPDFWithDefaultTitleFont(t *testing.T) {
+	var render = func(style PDFStyle) []byte {
+		style.GeneratedAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+		var content bytes.Buffer
+		if err := (PDFRenderer{Style: &style}).Render(&content, &Summary{Title: "Bold title", Text: "First sentence."}); err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		return content.Bytes()
+	}
+
+	var boldStyle = DefaultPDFStyle()
+	boldStyle.TitleFontData = fonts.OpenSansBold
+	if !bytes.Equal(render(DefaultPDFStyle()), render(boldStyle)) {
+		t.Error("Expected the embedded bold font for the title")
+	}
+
+	var customStyle = DefaultPDFStyle()
+	customStyle.FontData = fonts.OpenSansRegular
+	var customTitleStyle = customStyle
+	customTitleStyle.TitleFontData = fonts.OpenSansRegular
+	if !bytes.Equal(render(customStyle), render(customTitleStyle)) {
+		t.Error("Expected the custom body font for the title")
+	}
+	if bytes.Equal(render(customStyle), render(boldStyle)) {
+		t.Error("Expected different pdf files for the regular and the bold title")
+	}
+}
+
+func TestAddingPDFInfoEntriesWithoutInfoDictionary(t *testing.T) {
+	var writer = &pdfWriter{infoEntries: "/Keywords " + encodePDFString("journey") + "\n"}
+
+	var content, err = writer.addInfoEntries([]byte("trailer\n<<\n/Size 3\n/Info <<\n/Title <FEFF>\n >>\n>>\n"))
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if !bytes.Contains(content, []byte("/Info <<\n/Keywords <FEFF")) {
+		t.Error("Expected the keywords in the document information but received: ", string(content))
+	}
+
+	if _, err = writer.addInfoEntries([]byte("trailer\n<<\n/Size 3\n>>\n")); err == nil {
+		t.Error("Expected error for pdf without document information but received none")
+	}
+}
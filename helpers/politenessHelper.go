@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hostState tracks the running requests and the next allowed request time for one host
+type hostState struct {
+	slots       chan struct{}
+	mutex       sync.Mutex
+	nextRequest time.Time
+}
+
+// hostLimiter limits the concurrency and the request rate per host
+type hostLimiter struct {
+	mutex sync.Mutex
+	hosts map[string]*hostState
+}
+
+func newHostLimiter() *hostLimiter {
+	var limiter = new(hostLimiter)
+	limiter.hosts = make(map[string]*hostState)
+	return limiter
+}
+
+func (l *hostLimiter) getHost(host string, maxConnections int) *hostState {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var state, exists = l.hosts[host]
+	if !exists {
+		state = new(hostState)
+		if maxConnections > 0 {
+			state.slots = make(chan struct{}, maxConnections)
+		}
+		l.hosts[host] = state
+	}
+
+	return state
+}
+
+// acquire waits until a request to the host is allowed. At most maxConnections requests
+// run at the same time and their starts are at least interval apart.
+// The returned function must be called when the request is done
+func (l *hostLimiter) acquire(ctx context.Context, host string, maxConnections int, interval time.Duration) (func(), error) {
+	var state = l.getHost(host, maxConnections)
+
+	var release = func() {}
+	if state.slots != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case state.slots <- struct{}{}:
+		}
+		release = func() { <-state.slots }
+	}
+
+	if interval <= 0 {
+		return release, nil
+	}
+
+	// Reserve the next free start time, so concurrent callers queue behind each other
+	state.mutex.Lock()
+	var now = time.Now()
+	var start = state.nextRequest
+	if start.Before(now) {
+		start = now
+	}
+	state.nextRequest = start.Add(interval)
+	state.mutex.Unlock()
+
+	var wait = start.Sub(now)
+	if wait <= 0 {
+		return release, nil
+	}
+
+	var timer = time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	case <-timer.C:
+		return release, nil
+	}
+}
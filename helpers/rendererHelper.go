@@ -0,0 +1,178 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// Renderer writes a summary in some format
+type Renderer interface {
+	Render(w io.Writer, summary *Summary) error
+}
+
+// RendererFunc is a function used as Renderer
+type RendererFunc func(w io.Writer, summary *Summary) error
+
+// Render calls the function
+func (f RendererFunc) Render(w io.Writer, summary *Summary) error {
+	return f(w, summary)
+}
+
+// PDFRenderer writes the summary as pdf with the style or with DefaultPDFStyle if it's nil.
+// The images are downloaded with the fetcher or with DefaultFetcher if it's nil.
+// Downloading is aborted when the context is done
+type PDFRenderer struct {
+	Context context.Context
+	Fetcher Fetcher
+	Style   *PDFStyle
+}
+
+// Render writes the title, the images and the summary text
+func (r PDFRenderer) Render(w io.Writer, summary *Summary) error {
+	var ctx = r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var style = DefaultPDFStyle()
+	if r.Style != nil {
+		style = *r.Style
+	}
+
+	return renderPDF(ctx, w, summary, r.Fetcher, style)
+}
+
+// RenderOptions are used by the renderers, which need more than the summary. Fetcher downloads the images,
+// PDFStyle is the layout of the pdf files and Images configure the images of the docx and epub files
+type RenderOptions struct {
+	Fetcher  Fetcher
+	PDFStyle *PDFStyle
+	Images   *ImageOptions
+}
+
+var renderersMutex sync.RWMutex
+var renderersByFormat = make(map[string]Renderer)
+var renderersByMIMEType = make(map[string]Renderer)
+
+func init() {
+	RegisterRenderer("txt", "text/plain", RendererFunc(renderText))
+	RegisterRenderer("md", "text/markdown", RendererFunc(renderMarkdown))
+	RegisterRenderer("json", "application/json", RendererFunc(renderJSON))
+	RegisterRenderer("html", "text/html", RendererFunc(renderHTMLReport))
+	RegisterRenderer("pdf", "application/pdf", PDFRenderer{})
+	RegisterRenderer("docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", DOCXRenderer{})
+	RegisterRenderer("epub", epubMimeType, EPUBRenderer{})
+}
+
+// RegisterRenderer registers the renderer for the format name, which is also the file extension used by
+// StoreTextToFile, and for the MIME type, which can be empty. Registering an existing format replaces its renderer
+func RegisterRenderer(format string, mimeType string, renderer Renderer) {
+	renderersMutex.Lock()
+	defer renderersMutex.Unlock()
+
+	renderersByFormat[strings.ToLower(strings.TrimPrefix(format, "."))] = renderer
+	if mimeType != "" {
+		renderersByMIMEType[normalizeMIMEType(mimeType)] = renderer
+	}
+}
+
+// GetRenderer returns the renderer registered for the format name or the MIME type,
+// like "md", ".md" or "text/markdown; charset=utf-8"
+func GetRenderer(formatOrMIMEType string) (Renderer, bool) {
+	renderersMutex.RLock()
+	defer renderersMutex.RUnlock()
+
+	if strings.Contains(formatOrMIMEType, "/") {
+		var renderer, found = renderersByMIMEType[normalizeMIMEType(formatOrMIMEType)]
+		return renderer, found
+	}
+
+	var renderer, found = renderersByFormat[strings.ToLower(strings.TrimPrefix(formatOrMIMEType, "."))]
+	return renderer, found
+}
+
+// RenderSummary writes the summary to the writer in the format with the given name or MIME type.
+// The pdf images are downloaded with the fetcher or with DefaultFetcher if it's nil
+func RenderSummary(ctx context.Context, w io.Writer, formatOrMIMEType string, summary *Summary, fetcher Fetcher) error {
+	return RenderSummaryWithOptions(ctx, w, formatOrMIMEType, summary, RenderOptions{Fetcher: fetcher})
+}
+
+// RenderSummaryWithOptions writes the summary to the writer in the format with the given name or MIME type,
+// passing the options to the renderers, which need them
+func RenderSummaryWithOptions(ctx context.Context, w io.Writer, formatOrMIMEType string, summary *Summary, options RenderOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var renderer, found = GetRenderer(formatOrMIMEType)
+	if !found {
+		return errors.New("Invalid file type")
+	}
+
+	return renderWithOptions(ctx, renderer, options).Render(w, summary)
+}
+
+// renderWithOptions passes the context and the options to the pdf, docx and epub renderers, if they are not set
+func renderWithOptions(ctx context.Context, renderer Renderer, options RenderOptions) Renderer {
+	switch typedRenderer := renderer.(type) {
+	case PDFRenderer:
+		if typedRenderer.Context == nil {
+			typedRenderer.Context = ctx
+		}
+		if typedRenderer.Fetcher == nil {
+			typedRenderer.Fetcher = options.Fetcher
+		}
+		if typedRenderer.Style == nil {
+			typedRenderer.Style = options.PDFStyle
+		}
+		return typedRenderer
+	case DOCXRenderer:
+		if typedRenderer.Context == nil {
+			typedRenderer.Context = ctx
+		}
+		if typedRenderer.Fetcher == nil {
+			typedRenderer.Fetcher = options.Fetcher
+		}
+		if options.Images != nil {
+			setImageOptions(&typedRenderer.MaxImages, &typedRenderer.ImageDir, &typedRenderer.AllowLocalImages, *options.Images)
+		}
+		return typedRenderer
+	case EPUBRenderer:
+		if typedRenderer.Context == nil {
+			typedRenderer.Context = ctx
+		}
+		if typedRenderer.Fetcher == nil {
+			typedRenderer.Fetcher = options.Fetcher
+		}
+		if options.Images != nil {
+			setImageOptions(&typedRenderer.MaxImages, &typedRenderer.ImageDir, &typedRenderer.AllowLocalImages, *options.Images)
+		}
+		return typedRenderer
+	}
+
+	return renderer
+}
+
+// setImageOptions sets the image settings of a renderer to the options, if they are not set
+func setImageOptions(maxImages *int, imageDir *string, allowLocalImages *bool, options ImageOptions) {
+	if *maxImages == 0 {
+		*maxImages = options.MaxImages
+	}
+	if *imageDir == "" {
+		*imageDir = options.ImageDir
+	}
+	if !*allowLocalImages {
+		*allowLocalImages = options.AllowLocalImages
+	}
+}
+
+func normalizeMIMEType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGettingRenderers(t *testing.T) {
+	for _, format := range []string{"md", ".MD", "text/markdown; charset=utf-8", "pdf", "application/json", "text/html"} {
+		if _, found := GetRenderer(format); !found {
+			t.Error("Expected renderer for ", format)
+		}
+	}
+
+	if _, found := GetRenderer("text/x-unknown"); found {
+		t.Error("Expected no renderer for unknown MIME type")
+	}
+}
+
+func TestRegisteringRenderer(t *testing.T) {
+	RegisterRenderer("slack", "application/x-slack", RendererFunc(func(w io.Writer, summary *Summary) error {
+		var _, err = io.WriteString(w, "*"+summary.Title+"*\n"+summary.Text)
+		return err
+	}))
+
+	var content bytes.Buffer
+	var summary = &Summary{Title: "News", Text: "First sentence."}
+	var err = RenderSummary(context.Background(), &content, "application/x-slack", summary, nil)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if content.String() != "*News*\nFirst sentence." {
+		t.Error("Expected custom rendering but received: ", content.String())
+	}
+
+	err = RenderSummary(context.Background(), &content, "rtf", summary, nil)
+	if err == nil {
+		t.Error("Expected error for unknown format but received none")
+	}
+}
+
+func TestRenderingWithImageOptions(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "render-images-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var encodePNG = func(content *bytes.Buffer, picture image.Image) error { return png.Encode(content, picture) }
+	ioutil.WriteFile(filepath.Join(directory, "offline.png"), createTestImage(t, 100, 100, encodePNG), 0644)
+	var fetcher = mapFetcher{
+		"https://test.test/1.png": createTestImage(t, 100, 100, encodePNG),
+		"https://test.test/2.png": createTestImage(t, 100, 100, encodePNG),
+		"https://test.test/3.png": createTestImage(t, 100, 100, encodePNG),
+	}
+	var summary = &Summary{
+		Title:  "Images",
+		Text:   "The summary has images.",
+		Images: []string{"https://test.test/1.png", "https://test.test/2.png", "https://test.test/3.png", "images/offline.png"},
+	}
+
+	var countImages = func(format string, options RenderOptions) int {
+		var content bytes.Buffer
+		if err := RenderSummaryWithOptions(context.Background(), &content, format, summary, options); err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		archive, err := zip.NewReader(bytes.NewReader(content.Bytes()), int64(content.Len()))
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+
+		var count = 0
+		for _, file := range archive.File {
+			if strings.HasSuffix(file.Name, ".png") {
+				count++
+			}
+		}
+		return count
+	}
+
+	for _, format := range []string{"docx", "epub"} {
+		if count := countImages(format, RenderOptions{Fetcher: fetcher}); count != 3 {
+			t.Error("Expected all 3 web images in "+format+" but received: ", count)
+		}
+		if count := countImages(format, RenderOptions{Fetcher: fetcher, Images: &ImageOptions{ImageDir: directory}}); count != 4 {
+			t.Error("Expected the offline image in "+format+" too but received: ", count)
+		}
+		if count := countImages(format, RenderOptions{Fetcher: fetcher, Images: &ImageOptions{MaxImages: 1}}); count != 1 {
+			t.Error("Expected one image in "+format+" but received: ", count)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var reportParagraphBreak, _ = regexp.Compile(`\n\s*\n\s*`)
+
+// reportStyle is inlined in the page, so the report doesn't need any other files
+const reportStyle = `body { font-family: Georgia, serif; max-width: 46em; margin: 2em auto; padding: 0 1em; line-height: 1.6; color: #222; }
+h1 { line-height: 1.2; }
+dl.metadata { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; color: #555; }
+dl.metadata dt { font-weight: bold; }
+dl.metadata dd { margin: 0; }
+section.summary { background: #f6f6f0; border-left: 4px solid #e0b400; padding: 0.5em 1.5em; }
+.keywords span { display: inline-block; background: #eee; border-radius: 3px; padding: 0 0.4em; margin: 0 0.2em 0.2em 0; }
+mark { background: #fff1a8; }
+mark:target { background: #ffd400; }
+figure img { max-width: 100%; }`
+
+// renderHTMLReport writes the summary as an html page with inlined style. The summary is at the top
+// and the full text is below it, with the summary sentences highlighted and linked from the summary.
+// Only http and https sources and images are linked
+func renderHTMLReport(w io.Writer, summary *Summary) error {
+	var content bytes.Buffer
+	var title = summary.Title
+	if title == "" {
+		title = "Summary"
+	}
+
+	content.WriteString("<!DOCTYPE html>\n<html")
+	if language := summary.Metadata[MetadataLanguage]; language != "" {
+		content.WriteString(` lang="` + html.EscapeString(language) + `"`)
+	}
+	content.WriteString(">\n<head>\n<meta charset=\"utf-8\">\n")
+	content.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	content.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	content.WriteString("<style>\n" + reportStyle + "\n</style>\n</head>\n<body>\n")
+	content.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+
+	writeReportMetadata(&content, summary)
+
+	var highlighted = getHighlightedSentences(summary)
+	content.WriteString("<section class=\"summary\">\n<h2>Summary</h2>\n<ol>\n")
+	for i, sentence := range summary.SentenceTexts() {
+		var text = html.EscapeString(sentence)
+		if highlighted[i] {
+			text = "<a href=\"#sentence-" + strconv.Itoa(i+1) + "\">" + text + "</a>"
+		}
+		content.WriteString("<li>" + text + "</li>\n")
+	}
+	content.WriteString("</ol>\n")
+
+	for _, chapter := range summary.Chapters {
+		if chapter.Summary == "" {
+			continue
+		}
+
+		content.WriteString("<h3>" + html.EscapeString(chapter.Title) + "</h3>\n<ul>\n")
+		for _, sentence := range strings.Split(chapter.Summary, "\n") {
+			content.WriteString("<li>" + html.EscapeString(sentence) + "</li>\n")
+		}
+		content.WriteString("</ul>\n")
+	}
+	content.WriteString("</section>\n")
+
+	if len(summary.Keywords) > 0 {
+		content.WriteString("<p class=\"keywords\">")
+		for _, keyword := range summary.Keywords {
+			content.WriteString("<span>" + html.EscapeString(keyword) + "</span>")
+		}
+		content.WriteString("</p>\n")
+	}
+
+	for _, image := range summary.Images {
+		if !isWebURL(image) {
+			continue
+		}
+		content.WriteString("<figure><img src=\"" + html.EscapeString(image) + "\" alt=\"\"></figure>\n")
+	}
+
+	if summary.FullText != "" {
+		content.WriteString("<section class=\"original\">\n<h2>Full text</h2>\n")
+		content.WriteString(highlightFullText(summary.FullText, summary.Sentences, highlighted))
+		content.WriteString("</section>\n")
+	}
+
+	content.WriteString("</body>\n</html>\n")
+
+	var _, err = w.Write(content.Bytes())
+	return err
+}
+
+func writeReportMetadata(content *bytes.Buffer, summary *Summary) {
+	var keys = make([]string, 0, len(summary.Metadata))
+	for key := range summary.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if summary.SourceURL == "" && len(keys) == 0 {
+		return
+	}
+
+	content.WriteString("<dl class=\"metadata\">\n")
+	if summary.SourceURL != "" {
+		var url = html.EscapeString(summary.SourceURL)
+		if isWebURL(summary.SourceURL) {
+			url = "<a href=\"" + url + "\">" + url + "</a>"
+		}
+		content.WriteString("<dt>Source</dt><dd>" + url + "</dd>\n")
+	}
+	for _, key := range keys {
+		content.WriteString("<dt>" + html.EscapeString(metadataLabel(key)) + "</dt>")
+		content.WriteString("<dd>" + html.EscapeString(summary.Metadata[key]) + "</dd>\n")
+	}
+	content.WriteString("</dl>\n")
+}
+
+// getHighlightedSentences returns the indexes of the sentences, which can be highlighted in the full text.
+// The sentences must be found in the text, in order and without overlapping
+func getHighlightedSentences(summary *Summary) map[int]bool {
+	var highlighted = make(map[int]bool)
+	var position = 0
+	for i, sentence := range summary.Sentences {
+		var end = sentence.Offset + len(sentence.Text)
+		if sentence.Offset < position || end > len(summary.FullText) || summary.FullText[sentence.Offset:end] != sentence.Text {
+			continue
+		}
+
+		highlighted[i] = true
+		position = end
+	}
+
+	return highlighted
+}
+
+// highlightFullText writes the paragraphs of the text, marking the highlighted sentences
+func highlightFullText(text string, sentences []SummarySentence, highlighted map[int]bool) string {
+	var result strings.Builder
+	var position = 0
+
+	var writePlain = func(plain string) {
+		var paragraphs = reportParagraphBreak.Split(html.EscapeString(plain), -1)
+		result.WriteString(strings.Join(paragraphs, "</p>\n<p>"))
+	}
+
+	result.WriteString("<p>")
+	for i, sentence := range sentences {
+		if !highlighted[i] {
+			continue
+		}
+
+		writePlain(text[position:sentence.Offset])
+		result.WriteString("<mark id=\"sentence-" + strconv.Itoa(i+1) + "\">" + html.EscapeString(sentence.Text) + "</mark>")
+		position = sentence.Offset + len(sentence.Text)
+	}
+	writePlain(strings.TrimRight(text[position:], "\n"))
+	result.WriteString("</p>\n")
+
+	var paragraphs = strings.Replace(result.String(), "<p></p>\n", "", -1)
+	return strings.Replace(paragraphs, "\n<p></p>", "", -1)
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoringHTMLFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "html-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var fullText = "Prices rose <again>. Nobody expected it.\n\nThe bank reacted quickly. It raised the rates."
+	var summary = &Summary{
+		Title:     "Markets & banks",
+		SourceURL: "http://test.test/markets",
+		Metadata:  map[string]string{MetadataAuthor: "News desk"},
+		FullText:  fullText,
+		Sentences: []SummarySentence{
+			{Text: "Prices rose <again>.", Offset: 0},
+			{Text: "Missing sentence.", Offset: -1},
+			{Text: "It raised the rates.", Offset: strings.Index(fullText, "It raised")},
+		},
+		Keywords: []string{"prices"},
+	}
+
+	var path = filepath.Join(directory, "summary.html")
+	stored, err := StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteOverwrite)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedParts = []string{
+		"<title>Markets &amp; banks</title>",
+		"<dt>Author</dt><dd>News desk</dd>",
+		"<li><a href=\"#sentence-1\">Prices rose &lt;again&gt;.</a></li>",
+		"<li>Missing sentence.</li>",
+		"<li><a href=\"#sentence-3\">It raised the rates.</a></li>",
+		"<span>prices</span>",
+		"<p><mark id=\"sentence-1\">Prices rose &lt;again&gt;.</mark> Nobody expected it.</p>\n" +
+			"<p>The bank reacted quickly. <mark id=\"sentence-3\">It raised the rates.</mark></p>",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(string(content), part) {
+			t.Error("Expected report to contain ", part, " but received: ", string(content))
+		}
+	}
+}
+
+func TestRenderingHTMLReportLinksOnlyWebAddresses(t *testing.T) {
+	var summary = &Summary{
+		Title:     "Unsafe links",
+		SourceURL: "javascript:alert(1)",
+		Text:      "First sentence.",
+		Images:    []string{"file:///etc/passwd", "https://test.test/image.png"},
+	}
+
+	var content strings.Builder
+	var err = RenderSummary(context.Background(), &content, "html", summary, nil)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var report = content.String()
+	if strings.Contains(report, "href=\"javascript:") || !strings.Contains(report, "<dd>javascript:alert(1)</dd>") {
+		t.Error("Expected the javascript source as plain text but received: ", report)
+	}
+	if strings.Contains(report, "file:") || !strings.Contains(report, "<img src=\"https://test.test/image.png\"") {
+		t.Error("Expected only the https image but received: ", report)
+	}
+}
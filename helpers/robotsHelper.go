@@ -0,0 +1,294 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsCacheDuration is how long a downloaded robots.txt file is used before it's downloaded again
+const robotsCacheDuration = 24 * time.Hour
+
+// robotsFailureCacheDuration is how long a failure to download robots.txt, which disallows
+// everything, is kept, so a single network error or server error doesn't block the host for long
+const robotsFailureCacheDuration = time.Minute
+
+// RobotsDisallowedError is returned when robots.txt of the host forbids fetching the url
+type RobotsDisallowedError struct {
+	URL string
+}
+
+func (e *RobotsDisallowedError) Error() string {
+	return "Fetching " + e.URL + " is disallowed by robots.txt"
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsGroup struct {
+	userAgents []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsFile struct {
+	groups []*robotsGroup
+}
+
+// allowAllRobots and disallowAllRobots are used when the robots.txt file can't be read
+var allowAllRobots = &robotsGroup{}
+var disallowAllRobots = &robotsGroup{rules: []robotsRule{{allow: false, pattern: "/"}}}
+
+// parseRobots parses the content of a robots.txt file into groups of rules
+func parseRobots(content string) *robotsFile {
+	var robots = new(robotsFile)
+	var currentGroup *robotsGroup
+	var lastLineWasUserAgent = false
+
+	var scanner = bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if commentIndex := strings.Index(line, "#"); commentIndex >= 0 {
+			line = line[:commentIndex]
+		}
+
+		var separatorIndex = strings.Index(line, ":")
+		if separatorIndex < 0 {
+			continue
+		}
+
+		var key = strings.ToLower(strings.TrimSpace(line[:separatorIndex]))
+		var value = strings.TrimSpace(line[separatorIndex+1:])
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the same group
+			if currentGroup == nil || !lastLineWasUserAgent {
+				currentGroup = new(robotsGroup)
+				robots.groups = append(robots.groups, currentGroup)
+			}
+			currentGroup.userAgents = append(currentGroup.userAgents, strings.ToLower(value))
+			lastLineWasUserAgent = true
+			continue
+		case "allow", "disallow":
+			// An empty disallow rule allows everything, so it can be ignored
+			if currentGroup != nil && value != "" {
+				currentGroup.rules = append(currentGroup.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			var seconds, err = strconv.ParseFloat(value, 64)
+			if currentGroup != nil && err == nil && seconds > 0 {
+				currentGroup.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+
+		lastLineWasUserAgent = false
+	}
+
+	return robots
+}
+
+// groupFor returns the group of the product token, like "go-summarizer", matched case-insensitively
+// as in RFC 9309, falling back to the "*" group and to an empty group if there is no match.
+// Multiple groups for the same product token are combined
+func (r *robotsFile) groupFor(productToken string) *robotsGroup {
+	productToken = strings.ToLower(productToken)
+
+	var matchingGroups = []*robotsGroup{}
+	var defaultGroups = []*robotsGroup{}
+	for _, group := range r.groups {
+		for _, groupAgent := range group.userAgents {
+			if groupAgent == productToken {
+				matchingGroups = append(matchingGroups, group)
+				break
+			}
+			if groupAgent == "*" {
+				defaultGroups = append(defaultGroups, group)
+				break
+			}
+		}
+	}
+
+	if len(matchingGroups) == 0 {
+		matchingGroups = defaultGroups
+	}
+
+	switch len(matchingGroups) {
+	case 0:
+		return allowAllRobots
+	case 1:
+		return matchingGroups[0]
+	}
+
+	var combinedGroup = new(robotsGroup)
+	for _, group := range matchingGroups {
+		combinedGroup.userAgents = append(combinedGroup.userAgents, group.userAgents...)
+		combinedGroup.rules = append(combinedGroup.rules, group.rules...)
+		if group.crawlDelay > combinedGroup.crawlDelay {
+			combinedGroup.crawlDelay = group.crawlDelay
+		}
+	}
+	return combinedGroup
+}
+
+// isAllowed checks the path against the group rules. The longest matching rule wins
+// and allow rules win over disallow rules of the same length
+func (g *robotsGroup) isAllowed(path string) bool {
+	var allowed = true
+	var matchedLength = -1
+
+	for _, rule := range g.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > matchedLength || (len(rule.pattern) == matchedLength && rule.allow) {
+			allowed = rule.allow
+			matchedLength = len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// matchRobotsPattern matches a path against a robots.txt pattern,
+// supporting the "*" wildcard and the "$" end anchor
+func matchRobotsPattern(pattern string, path string) bool {
+	var anchored = strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	var parts = strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	var position = len(parts[0])
+	for i := 1; i < len(parts); i++ {
+		var isLast = i == len(parts)-1
+		if isLast && anchored {
+			return strings.HasSuffix(path[position:], parts[i])
+		}
+
+		var index = strings.Index(path[position:], parts[i])
+		if index < 0 {
+			return false
+		}
+		position += index + len(parts[i])
+	}
+
+	return !anchored || position == len(path)
+}
+
+type robotsCacheEntry struct {
+	ready     chan struct{}
+	robots    *robotsFile
+	fallback  *robotsGroup
+	fetchedAt time.Time
+	duration  time.Duration
+	cancelled bool
+}
+
+// robotsCache downloads and keeps the robots.txt files per host
+type robotsCache struct {
+	mutex   sync.Mutex
+	entries map[string]*robotsCacheEntry
+}
+
+func newRobotsCache() *robotsCache {
+	var cache = new(robotsCache)
+	cache.entries = make(map[string]*robotsCacheEntry)
+	return cache
+}
+
+// groupFor returns the robots.txt group that applies to the product token for the url host
+func (c *robotsCache) groupFor(ctx context.Context, f *HTTPFetcher, pageURL *url.URL, productToken string) (*robotsGroup, error) {
+	var key = pageURL.Scheme + "://" + pageURL.Host
+
+	for {
+		var entry = c.getEntry(ctx, f, key)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-entry.ready:
+		}
+
+		// The download was cancelled by another caller, so we try again with our own context
+		if entry.cancelled {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if entry.robots == nil {
+			return entry.fallback, nil
+		}
+
+		return entry.robots.groupFor(productToken), nil
+	}
+}
+
+// getEntry returns the cache entry for the host, downloading robots.txt if it's missing or expired.
+// Failed downloads, which disallow everything, expire sooner than the downloaded files
+func (c *robotsCache) getEntry(ctx context.Context, f *HTTPFetcher, key string) *robotsCacheEntry {
+	c.mutex.Lock()
+	var entry, exists = c.entries[key]
+	if exists && (!isClosed(entry.ready) || time.Since(entry.fetchedAt) < entry.duration) {
+		c.mutex.Unlock()
+		return entry
+	}
+
+	entry = &robotsCacheEntry{ready: make(chan struct{})}
+	c.entries[key] = entry
+	c.mutex.Unlock()
+
+	entry.robots, entry.fallback = f.downloadRobots(ctx, key+"/robots.txt")
+	entry.fetchedAt = time.Now()
+	entry.duration = robotsCacheDuration
+	if entry.fallback == disallowAllRobots {
+		entry.duration = robotsFailureCacheDuration
+	}
+	if ctx.Err() != nil {
+		entry.cancelled = true
+		c.mutex.Lock()
+		delete(c.entries, key)
+		c.mutex.Unlock()
+	}
+	close(entry.ready)
+
+	return entry
+}
+
+// downloadRobots downloads and parses the robots.txt file. If it can't be read,
+// a client error allows everything and any other failure disallows everything
+func (f *HTTPFetcher) downloadRobots(ctx context.Context, robotsURL string) (*robotsFile, *robotsGroup) {
+	var content, err = f.fetchOnce(ctx, robotsURL)
+	if err == nil {
+		return parseRobots(string(content)), nil
+	}
+
+	if statusError, ok := err.(*HTTPStatusError); ok && statusError.StatusCode >= 400 && statusError.StatusCode < 500 && statusError.StatusCode != http.StatusTooManyRequests {
+		return nil, allowAllRobots
+	}
+
+	return nil, disallowAllRobots
+}
+
+func isClosed(channel chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,210 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRobotsContent = `# test robots file
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: go-summarizer
+User-agent: other-bot
+Disallow: /no-summaries
+Crawl-delay: 1.5
+`
+
+func TestRobotsGroupMatching(t *testing.T) {
+	var robots = parseRobots(testRobotsContent)
+
+	var summarizerGroup = robots.groupFor(createTestFetcher().robotsToken())
+	if summarizerGroup.crawlDelay != 1500*time.Millisecond {
+		t.Error("Expected 1.5s crawl delay but received ", summarizerGroup.crawlDelay)
+	}
+
+	if summarizerGroup.isAllowed("/no-summaries/page") {
+		t.Error("Expected /no-summaries/page to be disallowed for go-summarizer")
+	}
+
+	var otherGroup = robots.groupFor("some-browser/1.0")
+	if !otherGroup.isAllowed("/no-summaries/page") {
+		t.Error("Expected /no-summaries/page to be allowed for other user agents")
+	}
+}
+
+func TestRobotsGroupMatchingByProductToken(t *testing.T) {
+	var robots = parseRobots(testRobotsContent)
+
+	if robots.groupFor("Go-Summarizer").crawlDelay != 1500*time.Millisecond {
+		t.Error("Expected the product token to be matched case-insensitively")
+	}
+	if robots.groupFor("my-go-summarizer-fork").crawlDelay != 0 {
+		t.Error("Expected a product token containing a group name to use the * group")
+	}
+
+	var fetcher = createTestFetcher()
+	fetcher.UserAgent = "Other-Bot/2.1 (+https://other.test)"
+	if fetcher.robotsToken() != "Other-Bot" {
+		t.Error("Expected 'Other-Bot' product token but received: ", fetcher.robotsToken())
+	}
+	if robots.groupFor(fetcher.robotsToken()).isAllowed("/no-summaries/page") {
+		t.Error("Expected /no-summaries/page to be disallowed for other-bot")
+	}
+}
+
+func TestRobotsRulesMatching(t *testing.T) {
+	var group = parseRobots(testRobotsContent).groupFor("some-browser/1.0")
+
+	var expectations = map[string]bool{
+		"/":                    true,
+		"/private":             false,
+		"/private/secret":      false,
+		"/private/public/page": true,
+		"/files/report.pdf":    false,
+		"/files/report.pdf?x":  true,
+		"/files/report.html":   true,
+	}
+
+	for path, expected := range expectations {
+		if group.isAllowed(path) != expected {
+			t.Error("Expected allowed to be ", expected, " for path: ", path)
+		}
+	}
+}
+
+func TestFetchingRespectsRobots(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.RespectRobots = true
+
+	var _, err = fetcher.Fetch(context.Background(), server.URL+"/private/page")
+	var disallowedError *RobotsDisallowedError
+	if !errors.As(err, &disallowedError) {
+		t.Error("Expected RobotsDisallowedError but received: ", err)
+	}
+
+	body, err := fetcher.Fetch(context.Background(), server.URL+"/public/page")
+	if err != nil || string(body) != "ok" {
+		t.Error("Expected public page to be fetched but received error: ", err)
+	}
+}
+
+func TestFetchingAfterRobotsFailureExpires(t *testing.T) {
+	var robotsFailing = true
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" && robotsFailing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.RespectRobots = true
+
+	var _, err = fetcher.Fetch(context.Background(), server.URL+"/page")
+	var disallowedError *RobotsDisallowedError
+	if !errors.As(err, &disallowedError) {
+		t.Error("Expected RobotsDisallowedError but received: ", err)
+	}
+
+	robotsFailing = false
+	var entry = fetcher.getRobots().entries[server.URL]
+	entry.fetchedAt = entry.fetchedAt.Add(-2 * robotsFailureCacheDuration)
+
+	body, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	if err != nil || string(body) != "ok" {
+		t.Error("Expected the page to be fetched after the failure expired but received error: ", err)
+	}
+}
+
+func TestFetchingWithMissingRobots(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.RespectRobots = true
+
+	var _, err = fetcher.Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Error("Didn't expect error but received: ", err.Error())
+	}
+}
+
+func TestFetchingLimitsHostConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	var running = 0
+	var maxRunning = 0
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.MaxConnsPerHost = 2
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			fetcher.Fetch(context.Background(), server.URL)
+		}()
+	}
+	waitGroup.Wait()
+
+	if maxRunning > 2 {
+		t.Error("Expected at most 2 concurrent requests but received ", maxRunning)
+	}
+}
+
+func TestFetchingWaitsHostInterval(t *testing.T) {
+	var server = createTestServer("ok")
+	defer server.Close()
+
+	var fetcher = createTestFetcher()
+	fetcher.HostInterval = 30 * time.Millisecond
+
+	var start = time.Now()
+	for i := 0; i < 3; i++ {
+		fetcher.Fetch(context.Background(), server.URL)
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Error("Expected at least 60ms for 3 requests but they took ", elapsed)
+	}
+}
@@ -1,54 +1,48 @@
 package helpers
 
 import (
-	"fmt"
+	"bytes"
 	"io"
-	"net/http"
-	"os"
+	"strconv"
 )
 
-func readFromReader(reader io.Reader) ([]byte, error) {
-	var resultBytes []byte
-	b := make([]byte, 1024)
-	var err error
-	var bytesRead int
+// MaxBodySize is the maximum number of bytes read from a single response body, from a single file of an office
+// document and from a decompressed pdf stream. New fetchers copy it, so DefaultFetcher.MaxBodySize must be
+// changed for DefaultFetcher. Zero or a negative value disables the limit
+var MaxBodySize int64 = 10 * 1024 * 1024
 
-	for err != io.EOF {
-		bytesRead, err = reader.Read(b)
-		if err != nil && bytesRead == 0 {
-			fmt.Println("error occurred: ", err.Error(), "\nbytes read: ", bytesRead)
-			return nil, err
-		}
-
-		resultBytes = append(resultBytes, b...)
-	}
-
-	return resultBytes, nil
+// BodyTooLargeError is returned when a body exceeds the allowed maximum size
+type BodyTooLargeError struct {
+	Limit int64
 }
 
-func saveImageFromURL(imageURL string, imagePath string) error {
-	// don't worry about errors
-	response, e := http.Get(imageURL)
+func (e *BodyTooLargeError) Error() string {
+	return "Body exceeds the maximum allowed size of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+}
 
-	if response != nil && response.Body != nil {
-		defer response.Body.Close()
-	}
+// readFromReader reads the whole reader, failing with BodyTooLargeError
+// if more than maxBytes are available. A maxBytes of zero or below means no limit
+func readFromReader(reader io.Reader, maxBytes int64) ([]byte, error) {
+	var buffer bytes.Buffer
+	if maxBytes <= 0 {
+		_, err := buffer.ReadFrom(reader)
+		if err != nil {
+			return nil, err
+		}
 
-	if e != nil {
-		return e
+		return buffer.Bytes(), nil
 	}
 
-	//open a file for writing
-	file, err := os.Create(imagePath)
+	// Read one byte more than allowed so we can tell an exact fit from an overflow
+	var limitedReader = io.LimitReader(reader, maxBytes+1)
+	bytesRead, err := buffer.ReadFrom(limitedReader)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Use io.Copy to just dump the response body to the file. This supports huge files
-	_, err = io.Copy(file, response.Body)
-	if err != nil {
-		return err
+
+	if bytesRead > maxBytes {
+		return nil, &BodyTooLargeError{Limit: maxBytes}
 	}
-	defer file.Close()
 
-	return nil
+	return buffer.Bytes(), nil
 }
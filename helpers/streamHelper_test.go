@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func createTestServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func TestReadingFromReaderKeepsExactBody(t *testing.T) {
+	// 2500 bytes is not a multiple of any common buffer size
+	var body = strings.Repeat("abcde", 500)
+	var result, err = readFromReader(iotest.HalfReader(strings.NewReader(body)), 0)
+	if err != nil {
+		t.Error("Didn't expect error but received: ", err.Error())
+	}
+
+	if string(result) != body {
+		t.Error("Expected ", len(body), " bytes but received ", len(result))
+	}
+}
+
+func TestReadingFromReaderWithExactLimit(t *testing.T) {
+	var body = strings.Repeat("a", 100)
+	var result, err = readFromReader(strings.NewReader(body), 100)
+	if err != nil {
+		t.Error("Didn't expect error but received: ", err.Error())
+	}
+
+	if len(result) != 100 {
+		t.Error("Expected 100 bytes but received ", len(result))
+	}
+}
+
+func TestReadingFromReaderOverLimit(t *testing.T) {
+	var body = strings.Repeat("a", 101)
+	var _, err = readFromReader(strings.NewReader(body), 100)
+
+	var tooLargeError *BodyTooLargeError
+	if !errors.As(err, &tooLargeError) {
+		t.Fatal("Expected BodyTooLargeError but received: ", err)
+	}
+
+	if tooLargeError.Limit != 100 {
+		t.Error("Expected limit 100 but received ", tooLargeError.Limit)
+	}
+}
+
+func TestReadingFromReaderReturnsReadErrors(t *testing.T) {
+	var readError = errors.New("test read error")
+	var _, err = readFromReader(iotest.ErrReader(readError), 0)
+	if err != readError {
+		t.Error("Expected read error but received: ", err)
+	}
+}
+
+func TestGettingHTMLFromURLKeepsExactBody(t *testing.T) {
+	var body = "<html><body>" + strings.Repeat("<p>paragraph text</p>", 300) + "</body></html>"
+	var server = createTestServer(body)
+	defer server.Close()
+
+	var htmlString, err = GetHTMLFromURL(context.Background(), nil, server.URL)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if htmlString != body {
+		t.Error("Expected body of ", len(body), " bytes but received ", len(htmlString))
+	}
+}
+
+func TestGettingHTMLFromURLOverMaxBodySize(t *testing.T) {
+	var server = createTestServer(strings.Repeat("a", 2048))
+	defer server.Close()
+
+	var originalMaxBodySize = DefaultFetcher.MaxBodySize
+	DefaultFetcher.MaxBodySize = 1024
+	defer func() { DefaultFetcher.MaxBodySize = originalMaxBodySize }()
+
+	var _, err = GetHTMLFromURL(context.Background(), nil, server.URL)
+	var tooLargeError *BodyTooLargeError
+	if !errors.As(err, &tooLargeError) {
+		t.Error("Expected BodyTooLargeError but received: ", err)
+	}
+}
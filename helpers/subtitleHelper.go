@@ -0,0 +1,281 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Cue is a piece of timed text. Documents read from subtitles have one cue per sentence
+// and Offset is the byte offset of the cue text in the document text
+type Cue struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+	Offset  int
+}
+
+// Limits for grouping the subtitle sentences into paragraphs
+const (
+	subtitlePauseGap           = 2 * time.Second
+	subtitleSentenceGap        = 1500 * time.Millisecond
+	subtitleMaxSentenceWords   = 40
+	subtitleParagraphSentences = 5
+)
+
+var cueTiming, _ = regexp.Compile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+var cueVoice, _ = regexp.Compile(`<v(?:\.[^ >]*)?\s+([^>]+)>`)
+var cueTags, _ = regexp.Compile(`<[^>]*>|\{\\[^}]*\}`)
+var sentenceEnd, _ = regexp.Compile(`[.!?…]["'”’)\]]*$`)
+
+// decodeSubtitleText returns the subtitles as UTF-8. UTF-16 files are recognized by their byte order mark.
+// Subtitles, which are not UTF-8, have no charset information, so it's guessed from the letters -
+// Cyrillic (windows-1251), Greek (windows-1253) or western (windows-1252), when nothing else applies
+func decodeSubtitleText(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xfe")):
+		return decodeCharset(data[2:], "utf-16le")
+	case bytes.HasPrefix(data, []byte("\xfe\xff")):
+		return decodeCharset(data[2:], "utf-16be")
+	case utf8.Valid(data):
+		return string(data), nil
+	}
+
+	return decodeCharset(data, guessSingleByteCharset(data))
+}
+
+// guessSingleByteCharset tells the charset of a single byte encoded text. The letters of Cyrillic and Greek are
+// above 0xC0 and they make whole words, while the western accented letters are single letters in latin words.
+// Cyrillic and Greek are told apart by the most frequent letters - "о" and "а" are 0xEE and 0xE0 in windows-1251,
+// while they are the rare "ξ" and "ΰ" in windows-1253, where "ο" and "α" are 0xEF and 0xE1
+func guessSingleByteCharset(data []byte) string {
+	var letters, lettersInWords = 0, 0
+	var run = 0
+	var counts [256]int
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && data[i] >= 0xc0 {
+			counts[data[i]]++
+			letters++
+			run++
+			continue
+		}
+
+		if run >= 3 {
+			lettersInWords += run
+		}
+		run = 0
+	}
+
+	if letters == 0 || lettersInWords*2 < letters {
+		return "windows-1252"
+	}
+
+	if counts[0xee]+counts[0xe0] >= counts[0xef]+counts[0xe1] {
+		return "windows-1251"
+	}
+	return "windows-1253"
+}
+
+// readSubtitleDocument merges the cues of SRT or WebVTT subtitles into sentences,
+// keeping the time of the first and the last cue of every sentence
+func readSubtitleDocument(data []byte, format string) (*Document, error) {
+	var decoded, err = decodeSubtitleText(data)
+	if err != nil {
+		return nil, err
+	}
+	var text = normalizeText(decoded)
+
+	var title = ""
+	if format == FormatVTT {
+		var header = strings.SplitN(text, "\n", 2)[0]
+		if !strings.HasPrefix(header, "WEBVTT") {
+			return nil, errors.New("Invalid WebVTT header")
+		}
+		title = strings.TrimLeft(strings.TrimPrefix(header, "WEBVTT"), " \t-")
+	}
+
+	var cues = parseCues(text)
+	if len(cues) == 0 {
+		return nil, errors.New("The subtitles have no cues")
+	}
+
+	var document = &Document{Title: strings.TrimSpace(title)}
+	document.Cues = mergeCuesIntoSentences(removeRepeatedCueLines(cues))
+	document.Text = buildSubtitleText(document.Cues)
+	return document, nil
+}
+
+// parseCues reads the cue blocks. Blocks without timing, like the WebVTT header, notes and styles, are skipped
+func parseCues(text string) []Cue {
+	var cues = []Cue{}
+	for _, block := range strings.Split(text, "\n\n") {
+		var lines = strings.Split(strings.Trim(block, "\n"), "\n")
+
+		var timingLine = -1
+		for i := 0; i < len(lines) && i < 2; i++ {
+			if cueTiming.MatchString(lines[i]) {
+				timingLine = i
+				break
+			}
+		}
+		if timingLine < 0 {
+			continue
+		}
+
+		var match = cueTiming.FindStringSubmatch(lines[timingLine])
+		var cue = Cue{Start: parseCueTime(match[1]), End: parseCueTime(match[2])}
+
+		var textLines = []string{}
+		for _, line := range lines[timingLine+1:] {
+			if voice := cueVoice.FindStringSubmatch(line); voice != nil {
+				cue.Speaker = strings.TrimSpace(voice[1])
+			}
+
+			line = strings.TrimSpace(html.UnescapeString(cueTags.ReplaceAllString(line, "")))
+			if line != "" {
+				textLines = append(textLines, line)
+			}
+		}
+
+		cue.Text = strings.Join(textLines, "\n")
+		if cue.Text != "" {
+			cues = append(cues, cue)
+		}
+	}
+
+	return cues
+}
+
+// parseCueTime parses "hh:mm:ss,mmm" or "mm:ss.mmm" times
+func parseCueTime(value string) time.Duration {
+	var parts = strings.Split(strings.Replace(value, ",", ".", 1), ":")
+	var duration time.Duration
+	for _, part := range parts[:len(parts)-1] {
+		var number, _ = strconv.Atoi(part)
+		duration = duration*60 + time.Duration(number)
+	}
+
+	var seconds, _ = strconv.ParseFloat(parts[len(parts)-1], 64)
+	return duration*60*time.Second + time.Duration(seconds*float64(time.Second))
+}
+
+// removeRepeatedCueLines removes the lines repeated from the previous cue,
+// which is how the rolling captions of automatic transcripts are written
+func removeRepeatedCueLines(cues []Cue) []Cue {
+	var result = []Cue{}
+	var previousLines = []string{}
+
+	for _, cue := range cues {
+		var lines = strings.Split(cue.Text, "\n")
+		for len(lines) > 0 && len(previousLines) > 0 && lines[0] == previousLines[len(previousLines)-1] {
+			lines = lines[1:]
+		}
+		previousLines = strings.Split(cue.Text, "\n")
+
+		cue.Text = strings.Join(lines, " ")
+		if cue.Text != "" {
+			result = append(result, cue)
+		}
+	}
+
+	return result
+}
+
+// mergeCuesIntoSentences joins the cue fragments into sentences. A sentence ends with its punctuation,
+// with a change of the speaker, with a longer pause or when it gets too long without punctuation
+func mergeCuesIntoSentences(cues []Cue) []Cue {
+	var sentences = []Cue{}
+	var current = Cue{}
+	var words = []string{}
+
+	var endSentence = func() {
+		if len(words) == 0 {
+			return
+		}
+
+		current.Text = strings.Join(words, " ")
+		if !sentenceEnd.MatchString(current.Text) {
+			current.Text += "."
+		}
+		sentences = append(sentences, current)
+		words = []string{}
+	}
+
+	for i, cue := range cues {
+		var speakerChanged = i > 0 && cue.Speaker != cues[i-1].Speaker
+		var paused = i > 0 && cue.Start-cues[i-1].End >= subtitleSentenceGap
+		if speakerChanged || paused || len(words) >= subtitleMaxSentenceWords {
+			endSentence()
+		}
+
+		for _, word := range strings.Fields(cue.Text) {
+			if len(words) == 0 {
+				current = Cue{Start: cue.Start, Speaker: cue.Speaker}
+			}
+
+			words = append(words, word)
+			current.End = cue.End
+			if sentenceEnd.MatchString(word) {
+				endSentence()
+			}
+		}
+	}
+	endSentence()
+
+	return sentences
+}
+
+// buildSubtitleText joins the sentences into paragraphs, starting a new one after a pause,
+// a change of the speaker or a few sentences, and sets the offsets of the sentences in the text
+func buildSubtitleText(sentences []Cue) string {
+	var text strings.Builder
+	var paragraphSentences = 0
+
+	for i := range sentences {
+		if i > 0 {
+			var previous = sentences[i-1]
+			var newParagraph = sentences[i].Start-previous.End >= subtitlePauseGap ||
+				sentences[i].Speaker != previous.Speaker || paragraphSentences >= subtitleParagraphSentences
+
+			if newParagraph {
+				text.WriteString("\n\n")
+				paragraphSentences = 0
+			} else {
+				text.WriteString(" ")
+			}
+		}
+
+		sentences[i].Offset = text.Len()
+		text.WriteString(sentences[i].Text)
+		paragraphSentences++
+	}
+
+	return text.String()
+}
+
+// SetSentenceTimestamps sets the start and end time of the summary sentences from the cues
+// of the document, where they were found
+func SetSentenceTimestamps(sentences []SummarySentence, cues []Cue) {
+	for i := range sentences {
+		if sentences[i].Offset < 0 {
+			continue
+		}
+
+		var first = sentences[i].Offset
+		var last = first + len(sentences[i].Text) - 1
+		for _, cue := range cues {
+			if first >= cue.Offset && first < cue.Offset+len(cue.Text) {
+				sentences[i].Start = cue.Start
+			}
+			if last >= cue.Offset && last < cue.Offset+len(cue.Text) {
+				sentences[i].End = cue.End
+			}
+		}
+	}
+}
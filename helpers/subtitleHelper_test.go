@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+var testSRT = `1
+00:00:01,000 --> 00:00:03,000
+The quarterly results were better
+
+2
+00:00:03,200 --> 00:00:05,500
+than expected. <i>Sales grew</i> in every region.
+
+3
+00:01:10,000 --> 00:01:12,000
+After the break we discussed hiring
+`
+
+func TestReadingSRTSubtitles(t *testing.T) {
+	if format := DetectFormat("", []byte(testSRT)); format != FormatSRT {
+		t.Error("Expected srt format but received: ", format)
+	}
+
+	var document, err = ReadDocument([]byte(testSRT), FormatSRT, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedCues = []Cue{
+		{Start: time.Second, End: 5500 * time.Millisecond, Text: "The quarterly results were better than expected.", Offset: 0},
+		{Start: 3200 * time.Millisecond, End: 5500 * time.Millisecond, Text: "Sales grew in every region.", Offset: 49},
+		{Start: 70 * time.Second, End: 72 * time.Second, Text: "After the break we discussed hiring.", Offset: 78},
+	}
+	if len(document.Cues) != len(expectedCues) {
+		t.Fatal("Expected 3 sentences but received: ", document.Cues)
+	}
+	for i, cue := range expectedCues {
+		if document.Cues[i] != cue {
+			t.Error("Expected sentence ", cue, " but received: ", document.Cues[i])
+		}
+	}
+
+	var expectedText = "The quarterly results were better than expected. Sales grew in every region.\n\nAfter the break we discussed hiring."
+	if document.Text != expectedText {
+		t.Error("Expected paragraphs split on the pause but received: ", document.Text)
+	}
+}
+
+func TestReadingSubtitlesInOtherCharsets(t *testing.T) {
+	var subtitles = map[string]string{
+		"Резултатите бяха по-добри от очакваното.":          "\xd0\xe5\xe7\xf3\xeb\xf2\xe0\xf2\xe8\xf2\xe5 \xe1\xff\xf5\xe0 \xef\xee-\xe4\xee\xe1\xf0\xe8 \xee\xf2 \xee\xf7\xe0\xea\xe2\xe0\xed\xee\xf2\xee.",
+		"Τα αποτελέσματα ήταν καλύτερα από το αναμενόμενο.": "\xd4\xe1 \xe1\xf0\xef\xf4\xe5\xeb\xdd\xf3\xec\xe1\xf4\xe1 \xde\xf4\xe1\xed \xea\xe1\xeb\xfd\xf4\xe5\xf1\xe1 \xe1\xf0\xfc \xf4\xef \xe1\xed\xe1\xec\xe5\xed\xfc\xec\xe5\xed\xef.",
+		"Les résultats étaient meilleurs que prévu.":        "Les r\xe9sultats \xe9taient meilleurs que pr\xe9vu.",
+	}
+
+	for expectedText, encodedText := range subtitles {
+		var document, err = ReadDocument([]byte("1\n00:00:01,000 --> 00:00:03,000\n"+encodedText+"\n"), FormatSRT, "")
+		if err != nil {
+			t.Fatal("Didn't expect error but received: ", err.Error())
+		}
+		if document.Text != expectedText {
+			t.Error("Expected '"+expectedText+"' but received: ", document.Text)
+		}
+	}
+
+	// UTF-16 little endian with byte order mark
+	var utf16Data = []byte{0xff, 0xfe}
+	for _, unit := range utf16.Encode([]rune("1\n00:00:01,000 --> 00:00:03,000\nΚαλημέρα σας.\n")) {
+		utf16Data = binary.LittleEndian.AppendUint16(utf16Data, unit)
+	}
+	var document, err = ReadDocument(utf16Data, FormatSRT, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	if document.Text != "Καλημέρα σας." {
+		t.Error("Expected 'Καλημέρα σας.' but received: ", document.Text)
+	}
+}
+
+func TestReadingWebVTTSubtitles(t *testing.T) {
+	var vtt = "WEBVTT - Team meeting\n\nNOTE recorded automatically\n\nintro\n00:01.000 --> 00:02.000 align:start\n<v Anna>Welcome everyone\n\n" +
+		"00:02.000 --> 00:03.000\n<v Anna>Welcome everyone\nto the meeting &amp; the demo\n\n00:03.000 --> 00:04.000\n<v Ben>Thanks Anna!\n"
+
+	var document, err = ReadDocument([]byte(vtt), FormatVTT, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if document.Title != "Team meeting" {
+		t.Error("Expected the title from the header but received: ", document.Title)
+	}
+
+	if document.Text != "Welcome everyone to the meeting & the demo.\n\nThanks Anna!" {
+		t.Error("Expected the rolling captions merged and split by speaker but received: ", document.Text)
+	}
+
+	if document.Cues[0].Speaker != "Anna" || document.Cues[0].End != 3*time.Second {
+		t.Error("Expected the first sentence of Anna until the third second but received: ", document.Cues[0])
+	}
+}
+
+func TestSummarySentenceTimestamps(t *testing.T) {
+	var document, err = ReadDocument([]byte(testSRT), FormatSRT, "")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	sentences, err := GetSummarySentences(context.Background(), document.Text)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	SetSentenceTimestamps(sentences, document.Cues)
+	if len(sentences) != 1 {
+		t.Fatal("Expected one summary sentence but received: ", sentences)
+	}
+
+	for _, cue := range document.Cues {
+		if strings.Contains(cue.Text, sentences[0].Text) && (sentences[0].Start != cue.Start || sentences[0].End != cue.End) {
+			t.Error("Expected the timing of the cue ", cue, " but received: ", sentences[0])
+		}
+	}
+
+	if sentences[0].Timestamp() != "00:00:01" && sentences[0].Timestamp() != "00:00:03" {
+		t.Error("Expected timestamp of the sentence but received: ", sentences[0].Timestamp())
+	}
+
+	if FormatTimestamp(3723*time.Second) != "01:02:03" {
+		t.Error("Expected hh:mm:ss timestamp but received: ", FormatTimestamp(3723*time.Second))
+	}
+}
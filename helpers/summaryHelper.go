@@ -0,0 +1,151 @@
+package helpers
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+)
+
+// Summary is the result of summarizing, which is stored to files.
+// FullText is the summarized text, where the sentence offsets point
+type Summary struct {
+	Title     string
+	SourceURL string
+	Metadata  map[string]string
+	Text      string
+	FullText  string
+	Sentences []SummarySentence
+	Keywords  []string
+	Images    []string
+	Chapters  []Chapter
+	Stats     SummaryStats
+}
+
+// SentenceTexts returns the sentences of the summary. If the summary has only text, it's split by lines
+func (s *Summary) SentenceTexts() []string {
+	var texts = []string{}
+	if len(s.Sentences) > 0 {
+		for _, sentence := range s.Sentences {
+			texts = append(texts, sentence.Text)
+		}
+		return texts
+	}
+
+	for _, line := range strings.Split(s.Text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			texts = append(texts, strings.TrimSpace(line))
+		}
+	}
+
+	return texts
+}
+
+type jsonSentence struct {
+	Text   string   `json:"text"`
+	Score  float32  `json:"score"`
+	Offset int      `json:"offset"`
+	Start  *float64 `json:"start,omitempty"`
+	End    *float64 `json:"end,omitempty"`
+}
+
+type jsonChapter struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+type jsonTextStats struct {
+	Runes      int `json:"runes"`
+	Words      int `json:"words"`
+	Sentences  int `json:"sentences"`
+	Paragraphs int `json:"paragraphs"`
+}
+
+type jsonStats struct {
+	OriginalLength    int           `json:"originalLength"`
+	SummaryLength     int           `json:"summaryLength"`
+	Original          jsonTextStats `json:"original"`
+	Summary           jsonTextStats `json:"summary"`
+	Ratio             float64       `json:"ratio"`
+	WordRatio         float64       `json:"wordRatio"`
+	SentenceRatio     float64       `json:"sentenceRatio"`
+	ParagraphRatio    float64       `json:"paragraphRatio"`
+	Images            int           `json:"images"`
+	ExtractionTime    float64       `json:"extractionSeconds,omitempty"`
+	SummarizationTime float64       `json:"summarizationSeconds,omitempty"`
+}
+
+type jsonSummary struct {
+	Title      string            `json:"title"`
+	SourceURL  string            `json:"sourceUrl,omitempty"`
+	Metadata   map[string]string `json:"metadata"`
+	Sentences  []jsonSentence    `json:"sentences"`
+	Keywords   []string          `json:"keywords"`
+	Images     []string          `json:"images"`
+	Chapters   []jsonChapter     `json:"chapters,omitempty"`
+	Statistics jsonStats         `json:"statistics"`
+}
+
+// MarshalJSON writes the summary with its sentences, keywords, images and statistics.
+// The sentences times and the timings of the statistics are in seconds. The sentences times are set only for timed media like subtitles
+func (s Summary) MarshalJSON() ([]byte, error) {
+	var result = jsonSummary{
+		Title:     s.Title,
+		SourceURL: s.SourceURL,
+		Metadata:  s.Metadata,
+		Sentences: []jsonSentence{},
+		Keywords:  s.Keywords,
+		Images:    s.Images,
+		Statistics: jsonStats{
+			OriginalLength:    s.Stats.Original.Runes,
+			SummaryLength:     s.Stats.Summary.Runes,
+			Original:          jsonTextStats(s.Stats.Original),
+			Summary:           jsonTextStats(s.Stats.Summary),
+			Ratio:             math.Round(s.Stats.Ratio*100) / 100,
+			WordRatio:         math.Round(s.Stats.WordRatio*100) / 100,
+			SentenceRatio:     math.Round(s.Stats.SentenceRatio*100) / 100,
+			ParagraphRatio:    math.Round(s.Stats.ParagraphRatio*100) / 100,
+			Images:            s.Stats.Images,
+			ExtractionTime:    s.Stats.ExtractionTime.Seconds(),
+			SummarizationTime: s.Stats.SummarizationTime.Seconds(),
+		},
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = map[string]string{}
+	}
+	if result.Keywords == nil {
+		result.Keywords = []string{}
+	}
+	if result.Images == nil {
+		result.Images = []string{}
+	}
+
+	if len(s.Sentences) > 0 {
+		for _, sentence := range s.Sentences {
+			var item = jsonSentence{Text: sentence.Text, Score: sentence.Score, Offset: sentence.Offset}
+			if sentence.Start != 0 || sentence.End != 0 {
+				var start, end = sentence.Start.Seconds(), sentence.End.Seconds()
+				item.Start, item.End = &start, &end
+			}
+			result.Sentences = append(result.Sentences, item)
+		}
+	} else {
+		for _, text := range s.SentenceTexts() {
+			result.Sentences = append(result.Sentences, jsonSentence{Text: text, Offset: -1})
+		}
+	}
+
+	for _, chapter := range s.Chapters {
+		result.Chapters = append(result.Chapters, jsonChapter{Title: chapter.Title, Summary: chapter.Summary})
+	}
+
+	return json.Marshal(result)
+}
+
+// renderJSON writes the summary as indented JSON
+func renderJSON(w io.Writer, summary *Summary) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetKeywords(t *testing.T) {
+	var content = "The summary of the text. A summary is shorter than the text, so the summary is useful. Go is fun."
+
+	var keywords = GetKeywords(content, 3)
+	var expectedKeywords = []string{"summary", "text", "shorter"}
+	if !reflect.DeepEqual(keywords, expectedKeywords) {
+		t.Error("Expected keywords ", expectedKeywords, " but received: ", keywords)
+	}
+}
+
+func TestStoringJSONFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "json-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var summary = &Summary{
+		Title:     "Quarterly results",
+		SourceURL: "http://test.test/results",
+		Metadata:  map[string]string{MetadataAuthor: "Finance team"},
+		Sentences: []SummarySentence{
+			{Text: "Results were good.", Score: 1.5, Offset: 0},
+			{Text: "Costs went down.", Score: 0.5, Offset: 40, Start: time.Second, End: 3 * time.Second},
+		},
+		Keywords: []string{"results", "costs"},
+		Stats:    GetSummaryStats("Results were good. Costs went up and then costs went down.", "Results were good.\nCosts went down.", 0),
+	}
+
+	var path = filepath.Join(directory, "summary.json")
+	stored, err := StoreTextToFileWithMode(context.Background(), path, summary, nil, WriteOverwrite)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatal("Expected valid JSON but received: ", err.Error())
+	}
+
+	if result["title"] != "Quarterly results" || result["sourceUrl"] != "http://test.test/results" {
+		t.Error("Expected title and source url but received: ", string(content))
+	}
+	if metadata, ok := result["metadata"].(map[string]interface{}); !ok || metadata[MetadataAuthor] != "Finance team" {
+		t.Error("Expected metadata but received: ", result["metadata"])
+	}
+
+	var sentences = result["sentences"].([]interface{})
+	var second = sentences[1].(map[string]interface{})
+	if len(sentences) != 2 || second["score"] != 0.5 || second["offset"] != 40.0 || second["start"] != 1.0 || second["end"] != 3.0 {
+		t.Error("Expected sentences with scores, offsets and times but received: ", sentences)
+	}
+	if _, hasStart := sentences[0].(map[string]interface{})["start"]; hasStart {
+		t.Error("Expected no times for untimed sentences but received: ", sentences[0])
+	}
+
+	var statistics = result["statistics"].(map[string]interface{})
+	if statistics["originalLength"] != 58.0 || statistics["summaryLength"] != 35.0 || statistics["ratio"] != 39.66 {
+		t.Error("Expected statistics but received: ", statistics)
+	}
+	if len(result["keywords"].([]interface{})) != 2 || len(result["images"].([]interface{})) != 0 {
+		t.Error("Expected keywords and empty images but received: ", string(content))
+	}
+}
@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	htmlTemplate "html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+)
+
+// SummaryTemplate is a user defined text or html template, which receives the whole summary.
+// The templates can use the Summary fields, like .Title, .Sentences, .Metadata, .Keywords, .Stats and .Images
+type SummaryTemplate struct {
+	template interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+}
+
+// templateFunctions are the functions available in the summary templates
+var templateFunctions = map[string]interface{}{
+	"join":      strings.Join,
+	"timestamp": FormatTimestamp,
+	"percent": func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64) + "%"
+	},
+}
+
+// ParseTextTemplate parses a text/template template for the summary
+func ParseTextTemplate(text string) (*SummaryTemplate, error) {
+	var template, err = textTemplate.New("summary").Funcs(templateFunctions).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SummaryTemplate{template: template}, nil
+}
+
+// ParseHTMLTemplate parses a html/template template for the summary, which escapes the summary values
+func ParseHTMLTemplate(text string) (*SummaryTemplate, error) {
+	var template, err = htmlTemplate.New("summary").Funcs(templateFunctions).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SummaryTemplate{template: template}, nil
+}
+
+// ParseTemplateFile parses the template from the file. Files with html or htm extension
+// are parsed as html/template and all others as text/template
+func ParseTemplateFile(path string) (*SummaryTemplate, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var extension = strings.ToLower(filepath.Ext(path))
+	if extension == ".html" || extension == ".htm" {
+		return ParseHTMLTemplate(string(content))
+	}
+
+	return ParseTextTemplate(string(content))
+}
+
+// Render executes the template with the summary
+func (t *SummaryTemplate) Render(w io.Writer, summary *Summary) error {
+	return t.template.Execute(w, summary)
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderingTextTemplate(t *testing.T) {
+	var template, err = ParseTextTemplate("{{.Title}} ({{.Metadata.author}})\n" +
+		"{{range .Sentences}}[{{timestamp .Start}}] {{.Text}}\n{{end}}Keywords: {{join .Keywords \", \"}}")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var summary = &Summary{
+		Title:     "Standup",
+		Metadata:  map[string]string{MetadataAuthor: "Team <lead>"},
+		Sentences: []SummarySentence{{Text: "Release is ready.", Start: 90 * time.Second}},
+		Keywords:  []string{"release", "ready"},
+	}
+
+	var content bytes.Buffer
+	if err := template.Render(&content, summary); err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var expectedContent = "Standup (Team <lead>)\n[00:01:30] Release is ready.\nKeywords: release, ready"
+	if content.String() != expectedContent {
+		t.Error("Expected rendered template but received: ", content.String())
+	}
+}
+
+func TestRenderingHTMLTemplateFile(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "template-test")
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	var templatePath = filepath.Join(directory, "digest.html")
+	ioutil.WriteFile(templatePath, []byte("<h1>{{.Title}}</h1>{{range .Images}}<img src=\"{{.}}\">{{end}}"), 0644)
+
+	template, err := ParseTemplateFile(templatePath)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	var path = filepath.Join(directory, "digest-output.html")
+	var summary = &Summary{Title: "Q&A <session>", Images: []string{"http://test.test/a.png"}}
+	stored, err := StoreRenderedToFile(path, summary, template, WriteFailIfExists)
+	if err != nil || !stored {
+		t.Fatal("Expected stored file but received: ", err)
+	}
+
+	content, _ := ioutil.ReadFile(path)
+	var expectedContent = "<h1>Q&amp;A &lt;session&gt;</h1><img src=\"http://test.test/a.png\">"
+	if string(content) != expectedContent {
+		t.Error("Expected escaped html but received: ", string(content))
+	}
+
+	if _, err := ParseTextTemplate("{{.Title"); err == nil {
+		t.Error("Expected error for invalid template but received none")
+	}
+}
@@ -1,8 +1,11 @@
 package helpers
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Naive method for splitting a text into sentences
@@ -87,7 +90,7 @@ func formatSentence(sentence string) string {
 	return replacedSentence
 }
 
-func getSentencesRanks(content string) map[string]float32 {
+func getSentencesRanks(ctx context.Context, content string) (map[string]float32, error) {
 	// Split the content into sentences
 	var sentences = getContentSentences(content)
 
@@ -96,6 +99,11 @@ func getSentencesRanks(content string) map[string]float32 {
 	var values = [][]float32{}
 
 	for i := 0; i < sentencesCount; i++ {
+		// The intersection is quadratic, so we stop early if the caller is no longer interested
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		values = append(values, []float32{})
 		for j := 0; j < sentencesCount; j++ {
 			if i == j {
@@ -122,7 +130,7 @@ func getSentencesRanks(content string) map[string]float32 {
 		sentencesDictionary[formatSentence(sentences[i])] = score
 	}
 
-	return sentencesDictionary
+	return sentencesDictionary, nil
 }
 
 // Return the best sentence in a paragraph
@@ -150,23 +158,76 @@ func getBestSentence(paragraph string, sentencesDictionary map[string]float32) s
 	return bestSentence
 }
 
+// SummarySentence is a sentence of the summary. Score is its rank in the text and Offset is its byte offset
+// in the summarized text or -1 if it can't be found. Start and End are set only for timed media like subtitles
+type SummarySentence struct {
+	Text   string
+	Score  float32
+	Offset int
+	Start  time.Duration
+	End    time.Duration
+}
+
+// Timestamp formats the start of the sentence as "hh:mm:ss". It's empty for sentences without timing
+func (s SummarySentence) Timestamp() string {
+	if s.Start == 0 && s.End == 0 {
+		return ""
+	}
+
+	return FormatTimestamp(s.Start)
+}
+
+// FormatTimestamp formats the duration as "hh:mm:ss"
+func FormatTimestamp(duration time.Duration) string {
+	var seconds = int(duration / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
 // GetSummary builds the summary from the given content text
 func GetSummary(content string) string {
+	var summary, _ = GetSummaryContext(context.Background(), content)
+	return summary
+}
+
+// GetSummaryContext builds the summary from the given content text.
+// It returns the context error if the context is done before the summary is ready
+func GetSummaryContext(ctx context.Context, content string) (string, error) {
+	var sentences, err = GetSummarySentences(ctx, content)
+	if err != nil {
+		return "", err
+	}
+
+	return JoinSummarySentences(sentences), nil
+}
+
+// JoinSummarySentences joins the sentences into the summary text
+func JoinSummarySentences(sentences []SummarySentence) string {
+	var texts = []string{}
+	for _, sentence := range sentences {
+		texts = append(texts, sentence.Text)
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+// GetSummarySentences returns the sentences of the summary in the order of the content, together with their scores
+func GetSummarySentences(ctx context.Context, content string) ([]SummarySentence, error) {
 	// Build the sentences dictionary
-	var sentencesDictionary = getSentencesRanks(content)
+	sentencesDictionary, err := getSentencesRanks(ctx, content)
+	if err != nil {
+		return nil, err
+	}
 
 	// Split the content into paragraphs
 	var paragraphs = getContentParagraphs(content)
 
-	// Add the title
-	var summary = []string{}
-
 	// Add the best sentence from each paragraph
+	var summary = []SummarySentence{}
 	for _, paragraph := range paragraphs {
 		var currentBestSentence = getBestSentence(paragraph, sentencesDictionary)
 		var sentence = strings.TrimSpace(currentBestSentence)
 		if sentence != "" {
-			summary = append(summary, sentence)
+			summary = append(summary, SummarySentence{Text: sentence, Score: sentencesDictionary[formatSentence(currentBestSentence)]})
 		}
 	}
 
@@ -174,10 +235,27 @@ func GetSummary(content string) string {
 		// Then we have one sentence per paragraph
 		// This way we combine all sentences in one paragraph
 		var newContent = strings.Replace(content, "\n\n", " ", -1)
-		var result = GetSummary(newContent)
-		return result
+		summary, err = GetSummarySentences(ctx, newContent)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var result = strings.Join(summary, "\n")
-	return result
+	LocateSentences(content, summary)
+	return summary, nil
+}
+
+// LocateSentences sets the offsets of the sentences in the text. The sentences are searched in order
+func LocateSentences(text string, sentences []SummarySentence) {
+	var position = 0
+	for i := range sentences {
+		var index = strings.Index(text[position:], sentences[i].Text)
+		if index < 0 {
+			sentences[i].Offset = -1
+			continue
+		}
+
+		sentences[i].Offset = position + index
+		position += index + len(sentences[i].Text)
+	}
 }
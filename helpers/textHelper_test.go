@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSummaryWithCancelledContext(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	var _, err = GetSummaryContext(ctx, "first sentence. second sentence")
+	if err != context.Canceled {
+		t.Error("Expected context.Canceled error but received: ", err)
+	}
+}
+
+func TestSummaryWithContext(t *testing.T) {
+	var summary, err = GetSummaryContext(context.Background(), "first sentence. second sentence")
+	if err != nil {
+		t.Error("Didn't expect error but received: ", err.Error())
+	}
+
+	if summary != GetSummary("first sentence. second sentence") {
+		t.Error("Expected the same summary as GetSummary but received: ", summary)
+	}
+}
+
+func TestSummarySentences(t *testing.T) {
+	var content = "The cat sat on the mat. The dog sat on the mat. Birds fly.\n\nThe mat was red. The cat liked the red mat."
+	var sentences, err = GetSummarySentences(context.Background(), content)
+	if err != nil {
+		t.Fatal("Didn't expect error but received: ", err.Error())
+	}
+
+	if JoinSummarySentences(sentences) != GetSummary(content) {
+		t.Error("Expected the sentences of GetSummary but received: ", sentences)
+	}
+
+	for _, sentence := range sentences {
+		if sentence.Offset < 0 || content[sentence.Offset:sentence.Offset+len(sentence.Text)] != sentence.Text {
+			t.Error("Expected the offset of the sentence in the content but received: ", sentence)
+		}
+		if sentence.Score <= 0 {
+			t.Error("Expected positive score but received: ", sentence)
+		}
+	}
+}
@@ -3,32 +3,140 @@ package helpers
 import (
 	"bytes"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// TextStats are the counts of the runes, words, sentences and paragraphs of a text
+type TextStats struct {
+	Runes      int
+	Words      int
+	Sentences  int
+	Paragraphs int
+}
+
+// SummaryStats are the statistics of the original text and the summary. The ratios are the percents,
+// by which the summary is shorter than the original text, measured in runes, words, sentences and paragraphs.
+// The timings are set by the summarizer and they are zero for cached summaries
+type SummaryStats struct {
+	Original          TextStats
+	Summary           TextStats
+	Ratio             float64
+	WordRatio         float64
+	SentenceRatio     float64
+	ParagraphRatio    float64
+	Images            int
+	ExtractionTime    time.Duration
+	SummarizationTime time.Duration
+}
+
+// GetSummaryStats calculates the statistics for the original text and summarized text.
+// The paragraphs of the original text are separated by empty lines and the paragraphs of the summary
+// are the paragraphs of the original text, which its sentences come from
+func GetSummaryStats(originalText string, summarizedText string, imagesCount int) SummaryStats {
+	var originalParagraphs = getContentParagraphs(originalText)
+	var stats = SummaryStats{
+		Original: getTextStats(originalText, originalParagraphs),
+		Summary:  getTextStats(summarizedText, getSummaryParagraphs(originalParagraphs, summarizedText)),
+		Images:   imagesCount,
+	}
+
+	stats.Ratio = getReductionRatio(stats.Original.Runes, stats.Summary.Runes)
+	stats.WordRatio = getReductionRatio(stats.Original.Words, stats.Summary.Words)
+	stats.SentenceRatio = getReductionRatio(stats.Original.Sentences, stats.Summary.Sentences)
+	stats.ParagraphRatio = getReductionRatio(stats.Original.Paragraphs, stats.Summary.Paragraphs)
+	return stats
+}
+
 // GetSummaryInfo Returns summary information statistics for the original text and summarized text
 func GetSummaryInfo(originalText string, summarizedText string, imagesCount int) string {
+	return GetSummaryStats(originalText, summarizedText, imagesCount).String()
+}
+
+// String returns the lengths in symbols, the ratio and the number of images, if there are any
+func (s SummaryStats) String() string {
 	// Print the ratio between the summary length and the original length
 	var summaryInfo bytes.Buffer
 
 	appendLine(&summaryInfo, "Summary info:")
 
-	var originalTextLength = float64(len(originalText))
-	var summarizedTextLength = float64(len(summarizedText))
-	var ratio = (100 - (100 * (summarizedTextLength / originalTextLength)))
-
-	var originalLengthString = strconv.FormatFloat(originalTextLength, 'f', -1, 64)
-	var summarizedLengthString = strconv.FormatFloat(summarizedTextLength, 'f', -1, 64)
-	var ratioString = strconv.FormatFloat(ratio, 'f', 2, 64)
+	var originalLengthString = strconv.Itoa(s.Original.Runes)
+	var summarizedLengthString = strconv.Itoa(s.Summary.Runes)
+	var ratioString = strconv.FormatFloat(s.Ratio, 'f', 2, 64)
 
 	appendLine(&summaryInfo, " - Original length: ", originalLengthString, " symbols")
 	appendLine(&summaryInfo, " - Summary length:  ", summarizedLengthString, " symbols")
 	appendLine(&summaryInfo, " - Summary ratio:   ", ratioString, "%")
-	if imagesCount > 0 {
-		appendLine(&summaryInfo, " - Images found:    ", strconv.Itoa(imagesCount))
+	if s.Images > 0 {
+		appendLine(&summaryInfo, " - Images found:    ", strconv.Itoa(s.Images))
+	}
+
+	return summaryInfo.String()
+}
+
+func getTextStats(text string, paragraphs []string) TextStats {
+	return TextStats{
+		Runes:      utf8.RuneCountInString(text),
+		Words:      len(strings.Fields(text)),
+		Sentences:  len(getContentSentences(text)),
+		Paragraphs: len(paragraphs),
+	}
+}
+
+// getContentLines returns the lines of the content, which are not empty
+func getContentLines(content string) []string {
+	var lines = []string{}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// getSummaryParagraphs returns the paragraphs of the original text, which contain the summary sentences,
+// given one per line. Sentences not found in the text count as own paragraphs, but the summary
+// never has more paragraphs than the original text
+func getSummaryParagraphs(originalParagraphs []string, summarizedText string) []string {
+	var normalizedParagraphs = make([]string, len(originalParagraphs))
+	for i, paragraph := range originalParagraphs {
+		normalizedParagraphs[i] = strings.Join(strings.Fields(paragraph), " ")
+	}
+
+	var found = make(map[int]bool)
+	var paragraphs = []string{}
+	for _, line := range getContentLines(summarizedText) {
+		var sentence = strings.Join(strings.Fields(line), " ")
+		var index = -1
+		for i, paragraph := range normalizedParagraphs {
+			if strings.Contains(paragraph, sentence) {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			paragraphs = append(paragraphs, line)
+		} else if !found[index] {
+			found[index] = true
+			paragraphs = append(paragraphs, originalParagraphs[index])
+		}
+	}
+
+	if len(originalParagraphs) > 0 && len(paragraphs) > len(originalParagraphs) {
+		paragraphs = paragraphs[:len(originalParagraphs)]
+	}
+	return paragraphs
+}
+
+// getReductionRatio returns the percent, by which the summary count is smaller than the original one, and 0 for empty texts
+func getReductionRatio(originalCount int, summaryCount int) float64 {
+	if originalCount == 0 {
+		return 0
 	}
 
-	var summaryInfoString = summaryInfo.String()
-	return summaryInfoString
+	return 100 - (100 * (float64(summaryCount) / float64(originalCount)))
 }
 
 func appendLine(mainString *bytes.Buffer, stringsToAppend ...string) {
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestSummaryStatsOfCyrillicText(t *testing.T) {
+	var original = "Умни машини обобщават текстове. Резюмето е кратко.\n\nВторият абзац има две изречения. Това е второто."
+	var summary = "Умни машини обобщават текстове\nВторият абзац има две изречения"
+
+	var stats = GetSummaryStats(original, summary, 1)
+	if stats.Original != (TextStats{Runes: 100, Words: 15, Sentences: 4, Paragraphs: 2}) {
+		t.Error("Expected 100 runes, 15 words, 4 sentences and 2 paragraphs but received: ", stats.Original)
+	}
+	if stats.Summary != (TextStats{Runes: 62, Words: 9, Sentences: 2, Paragraphs: 2}) {
+		t.Error("Expected 62 runes, 9 words, 2 sentences and 2 paragraphs but received: ", stats.Summary)
+	}
+	if stats.Ratio != 38 || stats.WordRatio != 40 || stats.SentenceRatio != 50 || stats.ParagraphRatio != 0 {
+		t.Error("Expected ratios 38, 40, 50 and 0 but received: ", stats.Ratio, stats.WordRatio, stats.SentenceRatio, stats.ParagraphRatio)
+	}
+
+	var expectedInfo = "Summary info:\n - Original length: 100 symbols\n - Summary length:  62 symbols\n - Summary ratio:   38.00%\n - Images found:    1\n"
+	if stats.String() != expectedInfo {
+		t.Error("Expected "+expectedInfo+" but received: ", stats.String())
+	}
+}
+
+func TestSummaryStatsOfEmptyText(t *testing.T) {
+	var stats = GetSummaryStats("", "", 0)
+	if stats.Ratio != 0 || stats.WordRatio != 0 || stats.SentenceRatio != 0 || stats.ParagraphRatio != 0 {
+		t.Error("Expected zero ratios for empty text but received: ", stats)
+	}
+
+	var expectedInfo = "Summary info:\n - Original length: 0 symbols\n - Summary length:  0 symbols\n - Summary ratio:   0.00%\n"
+	if stats.String() != expectedInfo {
+		t.Error("Expected "+expectedInfo+" but received: ", stats.String())
+	}
+}
+
+func TestSummaryStatsOfSingleParagraphText(t *testing.T) {
+	var original = "The first sentence is long. The second sentence is long. The third sentence is long. The fourth sentence is long."
+	var summary = "The first sentence is long.\nThe second sentence is long.\nThe fourth sentence is long."
+
+	var stats = GetSummaryStats(original, summary, 0)
+	if stats.Original.Paragraphs != 1 || stats.Summary.Paragraphs != 1 {
+		t.Error("Expected one paragraph in the text and in the summary but received: ", stats.Original.Paragraphs, stats.Summary.Paragraphs)
+	}
+	if stats.Summary.Sentences != 3 || stats.ParagraphRatio != 0 {
+		t.Error("Expected 3 sentences and paragraph ratio 0 but received: ", stats.Summary.Sentences, stats.ParagraphRatio)
+	}
+
+	stats = GetSummaryStats(original, "Another first sentence.\nAnother second sentence.", 0)
+	if stats.Summary.Paragraphs != 1 || stats.ParagraphRatio < 0 {
+		t.Error("Expected at most one paragraph for sentences not in the text but received: ", stats.Summary.Paragraphs, stats.ParagraphRatio)
+	}
+}
@@ -1,16 +1,16 @@
 package helpers
 
-import "net/http"
-import "regexp"
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+)
 
-func getHTMLFromURL(url string) (string, error) {
-	response, err := http.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer response.Body.Close()
-
-	htmlBytes, err := readFromReader(response.Body)
+// GetHTMLFromURL downloads the html of the given url with the given fetcher
+// or with DefaultFetcher if it's nil
+func GetHTMLFromURL(ctx context.Context, fetcher Fetcher, url string) (string, error) {
+	htmlBytes, err := getFetcher(fetcher).Fetch(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -19,15 +19,22 @@ func getHTMLFromURL(url string) (string, error) {
 	return htmlString, nil
 }
 
-// ExtractMainInfoFromURL searches the main content from the given url and returns the text and images
+// ExtractMainInfoFromURL searches the main content from the given url and returns the text and images.
+// The page is downloaded with DefaultFetcher
 func ExtractMainInfoFromURL(url string) (string, string, []string, error) {
-	var htmlString, err = getHTMLFromURL(url)
+	return ExtractMainInfoFromURLWithFetcher(context.Background(), nil, url)
+}
+
+// ExtractMainInfoFromURLWithFetcher searches the main content from the given url and returns the text and images.
+// The page is downloaded with the given fetcher or with DefaultFetcher if it's nil
+func ExtractMainInfoFromURLWithFetcher(ctx context.Context, fetcher Fetcher, url string) (string, string, []string, error) {
+	var htmlString, err = GetHTMLFromURL(ctx, fetcher, url)
 	if err != nil {
 		logError(err)
 		return "", "", nil, err
 	}
 
-	titleFromHTML, textFromHTML, imagesFromHTML, err := getMainInfoFromHTML(htmlString)
+	titleFromHTML, textFromHTML, imagesFromHTML, err := ExtractMainInfoFromHTML(htmlString)
 	if err != nil {
 		logError(err)
 		return "", "", nil, err
@@ -36,6 +43,11 @@ func ExtractMainInfoFromURL(url string) (string, string, []string, error) {
 	return titleFromHTML, textFromHTML, imagesFromHTML, nil
 }
 
+// ExtractMainInfoFromHTML searches the main content from the given html and returns the title, text and images
+func ExtractMainInfoFromHTML(htmlString string) (string, string, []string, error) {
+	return getMainInfoFromHTML(htmlString)
+}
+
 // IsURL checks if the given text is a website url address
 func IsURL(text string) bool {
 	var urlRegex, err = regexp.Compile("[-a-zA-Z0-9@:%._\\+~#=]{2,256}\\.[a-z]{2,6}\\b([-a-zA-Z0-9@:%_\\+.~#?&//=]*)")
@@ -46,3 +58,14 @@ func IsURL(text string) bool {
 	var isURL = urlRegex.MatchString(text)
 	return isURL
 }
+
+// isWebURL checks if the address is an absolute http or https url, which is safe to link from the rendered summaries
+func isWebURL(address string) bool {
+	var parsedURL, err = url.Parse(strings.TrimSpace(address))
+	if err != nil || parsedURL.Host == "" {
+		return false
+	}
+
+	var scheme = strings.ToLower(parsedURL.Scheme)
+	return scheme == "http" || scheme == "https"
+}
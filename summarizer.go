@@ -1,8 +1,14 @@
 package goSummarizer
 
 import (
+	"context"
 	"errors"
 	"goSummarizer/helpers"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Summarizer instance, used for extracting summary from raw texts and urls
@@ -13,37 +19,194 @@ type Summarizer struct {
 	summarizedText string
 	images         []string
 	summarized     bool
+	fetcher        helpers.Fetcher
+	summaryCache   helpers.Cache
+	cacheKey       string
+	maxPages       int
+	data           []byte
+	format         string
+	baseURL        string
+	chapters       []helpers.Chapter
+	cues           []helpers.Cue
+	sentences      []helpers.SummarySentence
+	metadata       map[string]string
+	writeMode      helpers.WriteMode
+	pdfStyle       *helpers.PDFStyle
+	imageOptions   *helpers.ImageOptions
+	extractionTime time.Duration
+	summarizeTime  time.Duration
+}
+
+// summaryCacheVersion is part of every summary cache key,
+// so changes in the summarizing algorithm don't reuse old results
+const summaryCacheVersion = "v2"
+
+// summaryKeywordsCount is the number of keywords of the summary result
+const summaryKeywordsCount = 10
+
+// Option configures optional behaviour of a summarizer instance
+type Option func(*Summarizer)
+
+// WithFetcher sets the fetcher used for downloading web pages and images.
+// helpers.DefaultFetcher is used if no fetcher is given
+func WithFetcher(fetcher helpers.Fetcher) Option {
+	return func(s *Summarizer) {
+		s.fetcher = fetcher
+	}
+}
+
+// WithSummaryCache sets a cache for the summaries. The summaries are keyed by the hash
+// of the downloaded html or the text, so unchanged content is not parsed and ranked again
+func WithSummaryCache(cache helpers.Cache) Option {
+	return func(s *Summarizer) {
+		s.summaryCache = cache
+	}
+}
+
+// WithMaxPages sets the maximum number of pages downloaded for articles split into multiple pages.
+// The default is helpers.DefaultMaxPages and 1 disables following the next page links
+func WithMaxPages(maxPages int) Option {
+	return func(s *Summarizer) {
+		if maxPages < 1 {
+			maxPages = 1
+		}
+		s.maxPages = maxPages
+	}
+}
+
+// WithWriteMode sets what StoreToFile does when the file already exists.
+// The default is helpers.WriteOverwrite
+func WithWriteMode(mode helpers.WriteMode) Option {
+	return func(s *Summarizer) {
+		s.writeMode = mode
+	}
+}
+
+// WithPDFStyle sets the page size, margins, fonts, footer and the other layout options of the pdf files.
+// The default is helpers.DefaultPDFStyle()
+func WithPDFStyle(style helpers.PDFStyle) Option {
+	return func(s *Summarizer) {
+		s.pdfStyle = &style
+	}
+}
+
+// WithImageOptions sets the maximum number of images and the local image sources of the docx and epub files.
+// By default all http and https images are embedded
+func WithImageOptions(options helpers.ImageOptions) Option {
+	return func(s *Summarizer) {
+		s.imageOptions = &options
+	}
 }
 
 // CreateFromURL creates summarizer instance, using the url parameter for summarizing
-func CreateFromURL(url string) *Summarizer {
+func CreateFromURL(url string, options ...Option) *Summarizer {
 	var summarizer = new(Summarizer)
 	summarizer.url = url
+	summarizer.maxPages = helpers.DefaultMaxPages
+	summarizer.applyOptions(options)
 	return summarizer
 }
 
 // CreateFromText creates summarizer instance, using the text parameter for summarizing
-func CreateFromText(text string) *Summarizer {
+func CreateFromText(text string, options ...Option) *Summarizer {
 	var summarizer = new(Summarizer)
 	summarizer.fullText = text
+	summarizer.applyOptions(options)
 	return summarizer
 }
 
+// CreateFromHTML creates summarizer instance, using the main content of the html for summarizing.
+// The baseURL is used for resolving relative image urls and can be empty
+func CreateFromHTML(html string, baseURL string, options ...Option) *Summarizer {
+	var summarizer = new(Summarizer)
+	summarizer.data = []byte(html)
+	summarizer.format = helpers.FormatHTML
+	summarizer.baseURL = baseURL
+	summarizer.applyOptions(options)
+	return summarizer
+}
+
+// CreateFromReader creates summarizer instance, using the content of the reader for summarizing.
+// The format of the content is detected by sniffing it
+func CreateFromReader(reader io.Reader, options ...Option) (*Summarizer, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return createFromData(data, helpers.DetectFormat("", data), options)
+}
+
+// CreateFromFile creates summarizer instance, using the content of the file for summarizing.
+// The format of the file is detected by its extension or by sniffing its content
+func CreateFromFile(filePath string, options ...Option) (*Summarizer, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return createFromData(data, helpers.DetectFormat(filePath, data), options)
+}
+
+func createFromData(data []byte, format string, options []Option) (*Summarizer, error) {
+	if format == "" {
+		return nil, &helpers.UnsupportedFormatError{}
+	}
+
+	var summarizer = new(Summarizer)
+	summarizer.data = data
+	summarizer.format = format
+	summarizer.applyOptions(options)
+	return summarizer, nil
+}
+
+func (s *Summarizer) applyOptions(options []Option) {
+	for _, option := range options {
+		option(s)
+	}
+}
+
 // Summarize returns summary of the text, extracted from the url or the saved text
 func (s *Summarizer) Summarize() (string, error) {
+	return s.SummarizeContext(context.Background())
+}
+
+// SummarizeContext returns summary of the text, extracted from the url or the saved text.
+// Fetching and ranking are aborted when the context is done
+func (s *Summarizer) SummarizeContext(ctx context.Context) (string, error) {
 	if s.IsSummarized() {
 		return s.summarizedText, nil
 	}
 
-	if s.fullText == "" && s.url == "" {
+	if s.fullText == "" && s.url == "" && s.data == nil {
 		return "", errors.New("You must submit text or url for summarizing")
 	}
 
 	if s.url != "" {
-		s.GetMainTextFromURL()
+		_, err := s.GetMainTextFromURLContext(ctx)
+		if err != nil {
+			return "", err
+		}
+	} else if s.data != nil {
+		err := s.readDocument()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	summarizedText, found := s.getCachedSummary()
+	if !found {
+		var start = time.Now()
+		var err error
+		summarizedText, err = s.summarizeFromText(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		s.summarizeTime = time.Since(start)
+		s.storeCachedSummary(summarizedText)
 	}
 
-	var summarizedText = s.summarizeFromText()
 	if len(summarizedText) == 0 {
 		return "", errors.New("Something happened while summarizing. Please try again")
 	}
@@ -60,6 +223,11 @@ func (s *Summarizer) Summarize() (string, error) {
 // GetMainTextFromURL parses the summarizer object URL property and returns the main text
 // from the website without ads, unnecessary images and other not important data
 func (s *Summarizer) GetMainTextFromURL() (string, error) {
+	return s.GetMainTextFromURLContext(context.Background())
+}
+
+// GetMainTextFromURLContext is like GetMainTextFromURL, but the download is aborted when the context is done
+func (s *Summarizer) GetMainTextFromURLContext(ctx context.Context) (string, error) {
 	if s.url == "" {
 		return "", errors.New("You must use summarizer from URL")
 	}
@@ -68,7 +236,20 @@ func (s *Summarizer) GetMainTextFromURL() (string, error) {
 		return s.fullText, nil
 	}
 
-	extractedTitle, extractedText, extractedImages, err := helpers.ExtractMainInfoFromURL(s.url)
+	var start = time.Now()
+	pages, err := helpers.GetPagesFromURL(ctx, s.fetcher, s.url, s.maxPages)
+	if err != nil {
+		return "", err
+	}
+
+	// If the pages are unchanged since the last summarizing, we don't have to parse them again
+	var pagesKey = "pages=" + strconv.Itoa(s.maxPages)
+	s.cacheKey = helpers.SummaryCacheKey(strings.Join(pages, "\x00"), summaryCacheVersion, "url", pagesKey)
+	if _, found := s.getCachedSummary(); found {
+		return s.title + "\n\n" + s.fullText, nil
+	}
+
+	extractedTitle, extractedText, extractedImages, err := helpers.ExtractMainInfoFromPages(pages)
 	if err != nil {
 		return "", err
 	}
@@ -76,24 +257,197 @@ func (s *Summarizer) GetMainTextFromURL() (string, error) {
 	s.title = extractedTitle
 	s.fullText = extractedText
 	s.images = extractedImages
+	s.metadata = helpers.ExtractMetadataFromHTML(pages[0])
+	s.extractionTime = time.Since(start)
 
 	return extractedTitle + "\n\n" + extractedText, nil
 }
 
-func (s *Summarizer) summarizeFromText() string {
+// readDocument reads the title, text and images from the local source data
+func (s *Summarizer) readDocument() error {
+	if s.fullText != "" {
+		return nil
+	}
+
+	s.cacheKey = helpers.SummaryCacheKey(string(s.data), summaryCacheVersion, s.format, s.baseURL)
+	if _, found := s.getCachedSummary(); found {
+		return nil
+	}
+
+	var start = time.Now()
+	document, err := helpers.ReadDocument(s.data, s.format, s.baseURL)
+	if err != nil {
+		return err
+	}
+
+	s.title = document.Title
+	s.fullText = document.Text
+	s.images = document.Images
+	s.chapters = document.Chapters
+	s.cues = document.Cues
+	s.metadata = document.Metadata
+	s.extractionTime = time.Since(start)
+	return nil
+}
+
+// getCachedSummary reads the summary from the summary cache and restores the extracted content with it
+func (s *Summarizer) getCachedSummary() (string, bool) {
+	if s.summaryCache == nil {
+		return "", false
+	}
+
+	if s.cacheKey == "" {
+		s.cacheKey = helpers.SummaryCacheKey(s.fullText, summaryCacheVersion, "text")
+	}
+
+	cachedSummary, found := helpers.GetCachedSummary(s.summaryCache, s.cacheKey)
+	if !found {
+		return "", false
+	}
+
+	s.title = cachedSummary.Title
+	s.fullText = cachedSummary.Text
+	s.images = cachedSummary.Images
+	s.chapters = cachedSummary.Chapters
+	s.sentences = cachedSummary.Sentences
+	s.metadata = cachedSummary.Metadata
+	return cachedSummary.Summary, true
+}
+
+func (s *Summarizer) storeCachedSummary(summarizedText string) {
+	if s.summaryCache == nil {
+		return
+	}
+
+	var cachedSummary = &helpers.CachedSummary{
+		Title:     s.title,
+		Text:      s.fullText,
+		Images:    s.images,
+		Summary:   summarizedText,
+		Chapters:  s.chapters,
+		Sentences: s.sentences,
+		Metadata:  s.metadata,
+	}
+
+	// Failing to cache the summary doesn't affect the result, so the error is ignored
+	helpers.StoreCachedSummary(s.summaryCache, s.cacheKey, cachedSummary)
+}
+
+func (s *Summarizer) summarizeFromText(ctx context.Context) (string, error) {
+	if len(s.chapters) > 0 {
+		return s.summarizeChapters(ctx)
+	}
+
 	// Build the summary with the sentences dictionary
-	var summary = helpers.GetSummary(s.fullText)
-	return summary
+	sentences, err := helpers.GetSummarySentences(ctx, s.fullText)
+	if err != nil {
+		return "", err
+	}
+
+	helpers.SetSentenceTimestamps(sentences, s.cues)
+	s.sentences = sentences
+	return helpers.JoinSummarySentences(sentences), nil
+}
+
+// summarizeChapters summarizes every chapter of a book on its own and builds
+// the book summary from the chapter summaries, so every chapter is represented in it
+func (s *Summarizer) summarizeChapters(ctx context.Context) (string, error) {
+	var chapterSummaries = []string{}
+	for i := range s.chapters {
+		chapterSummary, err := helpers.GetSummaryContext(ctx, s.chapters[i].Text)
+		if err != nil {
+			return "", err
+		}
+
+		s.chapters[i].Summary = chapterSummary
+		if chapterSummary != "" {
+			chapterSummaries = append(chapterSummaries, chapterSummary)
+		}
+	}
+
+	sentences, err := helpers.GetSummarySentences(ctx, strings.Join(chapterSummaries, "\n\n"))
+	if err != nil {
+		return "", err
+	}
+
+	// Chapter summaries of a single sentence are too short to be ranked, so they are used as they are
+	if len(sentences) == 0 {
+		for _, chapterSummary := range chapterSummaries {
+			for _, line := range strings.Split(chapterSummary, "\n") {
+				sentences = append(sentences, helpers.SummarySentence{Text: line})
+			}
+		}
+	}
+
+	// The offsets point to the book text instead of the chapter summaries
+	helpers.LocateSentences(s.fullText, sentences)
+	s.sentences = sentences
+	return helpers.JoinSummarySentences(sentences), nil
+}
+
+// GetChapterSummaries returns the title and summary of every chapter, if the summarized document is a book
+func (s *Summarizer) GetChapterSummaries() ([]helpers.Chapter, error) {
+	if !s.IsSummarized() {
+		return nil, errors.New("You must first summarize the text in order to get the chapter summaries")
+	}
+
+	var chapters = make([]helpers.Chapter, len(s.chapters))
+	copy(chapters, s.chapters)
+	return chapters, nil
 }
 
-// GetSummaryInfo returns summary information statistics if the text is summarized and an error if not
-func (s *Summarizer) GetSummaryInfo() (string, error) {
+// GetSummarySentences returns the sentences of the summary with their scores and offsets in the text.
+// Sentences of subtitles carry the start and end time of the cues they were said in
+func (s *Summarizer) GetSummarySentences() ([]helpers.SummarySentence, error) {
 	if !s.IsSummarized() {
-		return "", errors.New("You must first summarize the text in order to get information for it")
+		return nil, errors.New("You must first summarize the text in order to get the summary sentences")
 	}
 
-	var summaryInfo = helpers.GetSummaryInfo(s.fullText, s.summarizedText, len(s.images))
-	return summaryInfo, nil
+	var sentences = make([]helpers.SummarySentence, len(s.sentences))
+	copy(sentences, s.sentences)
+	return sentences, nil
+}
+
+// GetSummary returns the summary together with the title, source, metadata, sentences, images and chapters
+func (s *Summarizer) GetSummary() (*helpers.Summary, error) {
+	if !s.IsSummarized() {
+		return nil, errors.New("You must first summarize the text in order to get the summary")
+	}
+
+	var summary = &helpers.Summary{
+		Title:     s.title,
+		SourceURL: s.url,
+		Metadata:  make(map[string]string),
+		Text:      s.summarizedText,
+		FullText:  s.fullText,
+		Images:    append([]string{}, s.images...),
+	}
+	for key, value := range s.metadata {
+		summary.Metadata[key] = value
+	}
+	summary.Sentences, _ = s.GetSummarySentences()
+	summary.Chapters, _ = s.GetChapterSummaries()
+	summary.Keywords = helpers.GetKeywords(s.fullText, summaryKeywordsCount)
+	summary.Stats = s.getSummaryStats()
+
+	return summary, nil
+}
+
+// GetSummaryInfo returns summary information statistics if the text is summarized and an error if not.
+// Their String method returns the lengths and the ratio as text
+func (s *Summarizer) GetSummaryInfo() (helpers.SummaryStats, error) {
+	if !s.IsSummarized() {
+		return helpers.SummaryStats{}, errors.New("You must first summarize the text in order to get information for it")
+	}
+
+	return s.getSummaryStats(), nil
+}
+
+func (s *Summarizer) getSummaryStats() helpers.SummaryStats {
+	var stats = helpers.GetSummaryStats(s.fullText, s.summarizedText, len(s.images))
+	stats.ExtractionTime = s.extractionTime
+	stats.SummarizationTime = s.summarizeTime
+	return stats
 }
 
 // IsSummarized checks if the instance was already summarized
@@ -103,10 +457,110 @@ func (s *Summarizer) IsSummarized() bool {
 
 // StoreToFile stores the summarized text to the file from the given path
 func (s *Summarizer) StoreToFile(filePath string) (bool, error) {
+	return s.StoreToFileContext(context.Background(), filePath)
+}
+
+// StoreToFileContext stores the summarized text to the file from the given path.
+// Downloading images for the file is aborted when the context is done
+func (s *Summarizer) StoreToFileContext(ctx context.Context, filePath string) (bool, error) {
 	if !s.IsSummarized() {
 		return false, errors.New("You must first summarize the text in order to save the summary to a file")
 	}
 
-	stored, err := helpers.StoreTextToFile(filePath, s.title, s.summarizedText, s.images)
+	summary, err := s.GetSummary()
+	if err != nil {
+		return false, err
+	}
+
+	stored, err := helpers.StoreTextToFileWithOptions(ctx, filePath, summary, s.renderOptions(), s.writeMode)
 	return stored, err
 }
+
+// StoreToEPUB stores the summaries of the summarizers as EPUB book with the given title and one chapter per summarizer
+func StoreToEPUB(filePath string, title string, summarizers ...*Summarizer) (bool, error) {
+	return StoreToEPUBContext(context.Background(), filePath, title, summarizers...)
+}
+
+// StoreToEPUBContext stores the summaries of the summarizers as EPUB book with the given title and one chapter per summarizer.
+// The images are downloaded with the fetcher and the image options of the first summarizer and downloading is aborted
+// when the context is done
+func StoreToEPUBContext(ctx context.Context, filePath string, title string, summarizers ...*Summarizer) (bool, error) {
+	if len(summarizers) == 0 {
+		return false, errors.New("At least one summarizer is needed for an EPUB book")
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var summaries = []*helpers.Summary{}
+	for _, s := range summarizers {
+		if !s.IsSummarized() {
+			return false, errors.New("You must first summarize the text in order to save the summary to a file")
+		}
+
+		summary, err := s.GetSummary()
+		if err != nil {
+			return false, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	var renderer = helpers.EPUBRenderer{Context: ctx, Fetcher: summarizers[0].fetcher, Title: title}
+	if options := summarizers[0].imageOptions; options != nil {
+		renderer.MaxImages, renderer.ImageDir, renderer.AllowLocalImages = options.MaxImages, options.ImageDir, options.AllowLocalImages
+	}
+	return helpers.StoreSummariesToEPUB(filePath, summaries, renderer, summarizers[0].writeMode)
+}
+
+// Render writes the summary to the writer in the format with the given name or MIME type, like "md" or "application/json".
+// Other formats can be added with helpers.RegisterRenderer
+func (s *Summarizer) Render(w io.Writer, format string) error {
+	return s.RenderContext(context.Background(), w, format)
+}
+
+// RenderContext writes the summary to the writer in the format with the given name or MIME type.
+// Downloading images for pdf is aborted when the context is done
+func (s *Summarizer) RenderContext(ctx context.Context, w io.Writer, format string) error {
+	if !s.IsSummarized() {
+		return errors.New("You must first summarize the text in order to render the summary")
+	}
+
+	summary, err := s.GetSummary()
+	if err != nil {
+		return err
+	}
+
+	return helpers.RenderSummaryWithOptions(ctx, w, format, summary, s.renderOptions())
+}
+
+// RenderTemplate writes the summary to the writer with the user defined template
+func (s *Summarizer) RenderTemplate(w io.Writer, template *helpers.SummaryTemplate) error {
+	if !s.IsSummarized() {
+		return errors.New("You must first summarize the text in order to render the summary")
+	}
+
+	summary, err := s.GetSummary()
+	if err != nil {
+		return err
+	}
+
+	return template.Render(w, summary)
+}
+
+// StoreWithTemplate stores the summary rendered with the user defined template to the file from the given path
+func (s *Summarizer) StoreWithTemplate(filePath string, template *helpers.SummaryTemplate) (bool, error) {
+	if !s.IsSummarized() {
+		return false, errors.New("You must first summarize the text in order to save the summary to a file")
+	}
+
+	summary, err := s.GetSummary()
+	if err != nil {
+		return false, err
+	}
+
+	return helpers.StoreRenderedToFile(filePath, summary, template, s.writeMode)
+}
+
+func (s *Summarizer) renderOptions() helpers.RenderOptions {
+	return helpers.RenderOptions{Fetcher: s.fetcher, PDFStyle: s.pdfStyle, Images: s.imageOptions}
+}